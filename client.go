@@ -2,7 +2,12 @@ package actions
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v28/github"
 	"golang.org/x/oauth2"
@@ -25,3 +30,375 @@ func CreateClient(instToken, baseURL, uploadURL string) (*github.Client, error)
 	}
 	return github.NewClient(tc), nil
 }
+
+// ListPullRequestFiles returns every file changed in the pull request, going through all the pages
+func ListPullRequestFiles(client *github.Client, owner, repo string, num int) ([]*github.CommitFile, error) {
+	var files []*github.CommitFile
+
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, res, err := client.PullRequests.ListFiles(context.Background(), owner, repo, num, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, page...)
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	return files, nil
+}
+
+// ListPullRequestReviews returns every review of the pull request in the chronological order, going through all the pages
+func ListPullRequestReviews(client *github.Client, owner, repo string, num int) ([]*github.PullRequestReview, error) {
+	var reviews []*github.PullRequestReview
+
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, res, err := client.PullRequests.ListReviews(context.Background(), owner, repo, num, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, page...)
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	return reviews, nil
+}
+
+// LatestReviews returns the latest review of each user, keyed by login.
+// Comments don't change the state of the review, so COMMENTED and PENDING reviews are ignored.
+// A DISMISSED review overrides the review it dismissed.
+func LatestReviews(reviews []*github.PullRequestReview) map[string]*github.PullRequestReview {
+	latest := map[string]*github.PullRequestReview{}
+	for _, r := range reviews {
+		switch r.GetState() {
+		case "COMMENTED", "PENDING":
+			continue
+		}
+
+		login := r.GetUser().GetLogin()
+
+		if prev, ok := latest[login]; ok && r.GetSubmittedAt().Before(prev.GetSubmittedAt()) {
+			continue
+		}
+
+		latest[login] = r
+	}
+	return latest
+}
+
+// RemoveLabel removes the label from the issue or the pull request.
+// go-github puts the label into the path as-is, so it is escaped here for labels with slashes like `size/XS`.
+func RemoveLabel(client *github.Client, owner, repo string, num int, label string) error {
+	_, err := client.Issues.RemoveLabelForIssue(context.Background(), owner, repo, num, url.PathEscape(label))
+	return err
+}
+
+// ListTeamMemberLogins returns the logins of every member of the team, going through all the pages
+func ListTeamMemberLogins(client *github.Client, org, slug string) ([]string, error) {
+	team, _, err := client.Teams.GetTeamBySlug(context.Background(), org, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	var logins []string
+
+	opt := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		members, res, err := client.Teams.ListTeamMembers(context.Background(), team.GetID(), opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range members {
+			logins = append(logins, m.GetLogin())
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	return logins, nil
+}
+
+// ListPullRequestCommits returns every commit in the pull request from the oldest to the newest, going through all the pages
+func ListPullRequestCommits(client *github.Client, owner, repo string, num int) ([]*github.RepositoryCommit, error) {
+	var commits []*github.RepositoryCommit
+
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, res, err := client.PullRequests.ListCommits(context.Background(), owner, repo, num, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		commits = append(commits, page...)
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	return commits, nil
+}
+
+// ListOpenPullRequests returns every open pull request whose base branch is base, or every open pull request if base is empty, going through all the pages
+func ListOpenPullRequests(client *github.Client, owner, repo, base string) ([]*github.PullRequest, error) {
+	var pulls []*github.PullRequest
+
+	opt := &github.PullRequestListOptions{State: "open", Base: base, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, res, err := client.PullRequests.List(context.Background(), owner, repo, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		pulls = append(pulls, page...)
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	return pulls, nil
+}
+
+// ListLatestStatuses returns the latest status per context for the ref, going through all the pages of the combined status
+func ListLatestStatuses(client *github.Client, owner, repo, ref string) ([]github.RepoStatus, error) {
+	var statuses []github.RepoStatus
+
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		combined, res, err := client.Repositories.GetCombinedStatus(context.Background(), owner, repo, ref, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, combined.Statuses...)
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	return statuses, nil
+}
+
+// ListLatestCheckRuns returns the latest check run per name for the ref, going through all the pages
+func ListLatestCheckRuns(client *github.Client, owner, repo, ref string) ([]*github.CheckRun, error) {
+	var runs []*github.CheckRun
+
+	index := map[string]int{}
+
+	opt := &github.ListCheckRunsOptions{
+		Filter:      github.String("latest"),
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		res, resp, err := client.Checks.ListCheckRunsForRef(context.Background(), owner, repo, ref, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, run := range res.CheckRuns {
+			i, ok := index[run.GetName()]
+			if !ok {
+				index[run.GetName()] = len(runs)
+				runs = append(runs, run)
+			} else if run.GetStartedAt().After(runs[i].GetStartedAt().Time) {
+				runs[i] = run
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return runs, nil
+}
+
+// PushedAt returns when the head of the pull request was pushed to GitHub.
+// Committer dates are never used, as the author of the commits can set them to anything.
+func PushedAt(client *github.Client, owner, repo string, pr *github.PullRequest) (time.Time, error) {
+	var events []*github.Timeline
+
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, res, err := client.Issues.ListIssueTimeline(context.Background(), owner, repo, pr.GetNumber(), opt)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		events = append(events, page...)
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	suites, err := listCheckSuiteCreationTimes(client, owner, repo, pr.GetHead().GetSHA())
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return LastPushedAt(pr, events, suites), nil
+}
+
+// LastPushedAt returns the latest of the creation of the pull request, the force-pushes in its timeline,
+// and the creation of the first check suite for the head, which GitHub creates on the push.
+// When no check suite exists for the head, the time the pull request was last updated is used instead, as it is never before the push.
+func LastPushedAt(pr *github.PullRequest, events []*github.Timeline, suitesCreatedAt []time.Time) time.Time {
+	last := pr.GetCreatedAt()
+
+	for _, e := range events {
+		if e.GetEvent() == "head_ref_force_pushed" && e.GetCreatedAt().After(last) {
+			last = e.GetCreatedAt()
+		}
+	}
+
+	if len(suitesCreatedAt) == 0 {
+		if updated := pr.GetUpdatedAt(); updated.After(last) {
+			last = updated
+		}
+		return last
+	}
+
+	first := suitesCreatedAt[0]
+	for _, t := range suitesCreatedAt[1:] {
+		if t.Before(first) {
+			first = t
+		}
+	}
+
+	if first.After(last) {
+		last = first
+	}
+
+	return last
+}
+
+// listCheckSuiteCreationTimes returns the creation times of the check suites for the ref.
+// The response is decoded here as go-github's CheckSuite lacks `created_at`.
+func listCheckSuiteCreationTimes(client *github.Client, owner, repo, ref string) ([]time.Time, error) {
+	var times []time.Time
+
+	page := 1
+	for {
+		req, err := client.NewRequest("GET", fmt.Sprintf("repos/%v/%v/commits/%v/check-suites?per_page=100&page=%d", owner, repo, url.QueryEscape(ref), page), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github.antiope-preview+json")
+
+		var suites struct {
+			CheckSuites []struct {
+				CreatedAt time.Time `json:"created_at"`
+			} `json:"check_suites"`
+		}
+
+		res, err := client.Do(context.Background(), req, &suites)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range suites.CheckSuites {
+			times = append(times, s.CreatedAt)
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		page = res.NextPage
+	}
+
+	return times, nil
+}
+
+// ActionsBotLogin is the user that the GITHUB_TOKEN of GitHub Actions acts as
+const ActionsBotLogin = "github-actions[bot]"
+
+// AuthenticatedLogin returns the login of the user that the client authenticates as.
+// Installation tokens can't read the user, and are assumed to be the GITHUB_TOKEN of GitHub Actions.
+func AuthenticatedLogin(client *github.Client) (string, error) {
+	user, _, err := client.Users.Get(context.Background(), "")
+	if err != nil {
+		if e, ok := err.(*github.ErrorResponse); ok && e.Response != nil && e.Response.StatusCode == 403 {
+			return ActionsBotLogin, nil
+		}
+		return "", err
+	}
+	return user.GetLogin(), nil
+}
+
+// UpsertComment posts the body returned by render to the conversation of the issue or the pull request,
+// or edits the comment previously posted with the marker. render is given the previous comment, or nil when there is none.
+// Only comments of the authenticated user are edited, as anyone can paste the marker into a comment.
+func UpsertComment(client *github.Client, owner, repo string, num int, marker string, render func(*github.IssueComment) string) error {
+	login, err := AuthenticatedLogin(client)
+	if err != nil {
+		return err
+	}
+
+	var existing *github.IssueComment
+
+	opt := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for existing == nil {
+		comments, res, err := client.Issues.ListComments(context.Background(), owner, repo, num, opt)
+		if err != nil {
+			return err
+		}
+
+		for _, cm := range comments {
+			if cm.GetUser().GetLogin() == login && strings.Contains(cm.GetBody(), marker) {
+				existing = cm
+				break
+			}
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	body := render(existing)
+
+	if existing == nil {
+		created, _, err := client.Issues.CreateComment(context.Background(), owner, repo, num, &github.IssueComment{Body: github.String(body)})
+		if err != nil {
+			return err
+		}
+		log.Printf("Created comment %s", created.GetHTMLURL())
+		return nil
+	}
+
+	if existing.GetBody() == body {
+		log.Printf("Comment %s is up to date", existing.GetHTMLURL())
+		return nil
+	}
+
+	if _, _, err := client.Issues.EditComment(context.Background(), owner, repo, existing.GetID(), &github.IssueComment{Body: github.String(body)}); err != nil {
+		return err
+	}
+	log.Printf("Updated comment %s", existing.GetHTMLURL())
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package actions
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions/pkg/githubtest"
+)
+
+func TestLastPushedAt(t *testing.T) {
+	created := time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time {
+		return created.Add(time.Duration(hours) * time.Hour)
+	}
+
+	event := func(name string, hours int) *github.Timeline {
+		t := at(hours)
+		return &github.Timeline{Event: github.String(name), CreatedAt: &t}
+	}
+
+	testcases := []struct {
+		updated  time.Time
+		events   []*github.Timeline
+		suites   []time.Time
+		expected time.Time
+	}{
+		{
+			// A head pushed before the pull request was opened
+			suites:   []time.Time{at(-24)},
+			expected: created,
+		},
+		{
+			// The first check suite is created on the push. Later ones are re-runs
+			suites:   []time.Time{at(30), at(10), at(20)},
+			expected: at(10),
+		},
+		{
+			events:   []*github.Timeline{event("head_ref_force_pushed", 40), event("commented", 50)},
+			suites:   []time.Time{at(10)},
+			expected: at(40),
+		},
+		{
+			// Without check suites, the last update is never before the push
+			updated:  at(60),
+			events:   []*github.Timeline{event("head_ref_force_pushed", 40)},
+			expected: at(60),
+		},
+	}
+
+	for i, tc := range testcases {
+		pr := &github.PullRequest{CreatedAt: &created}
+		if !tc.updated.IsZero() {
+			pr.UpdatedAt = &tc.updated
+		}
+
+		if actual := LastPushedAt(pr, tc.events, tc.suites); !actual.Equal(tc.expected) {
+			t.Errorf("testcases[%d]: unexpected time: expected=%v, got=%v", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestUpsertComment(t *testing.T) {
+	comment := func(id int64, login, body string) *github.IssueComment {
+		return &github.IssueComment{ID: github.Int64(id), User: &github.User{Login: github.String(login)}, Body: github.String(body)}
+	}
+
+	testcases := []struct {
+		// user is the response body for the authenticated user, or empty for the 403 that installation tokens get
+		user     string
+		comments []*github.IssueComment
+		expected []string
+		prev     string
+	}{
+		{
+			user:     `{"login":"bot"}`,
+			comments: []*github.IssueComment{comment(1, "alice", "LGTM")},
+			expected: []string{"LGTM", "<!-- m -->\nnew"},
+		},
+		{
+			// Anyone can paste the marker, but only the comment of the authenticated user is edited
+			user:     `{"login":"bot"}`,
+			comments: []*github.IssueComment{comment(1, "mallory", "<!-- m -->"), comment(2, "bot", "<!-- m -->\nold")},
+			expected: []string{"<!-- m -->", "<!-- m -->\nnew"},
+			prev:     "<!-- m -->\nold",
+		},
+		{
+			comments: []*github.IssueComment{comment(1, "mallory", "<!-- m -->"), comment(2, ActionsBotLogin, "<!-- m -->\nold")},
+			expected: []string{"<!-- m -->", "<!-- m -->\nnew"},
+			prev:     "<!-- m -->\nold",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		s := githubtest.NewServer()
+
+		s.Mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+			if tc.user == "" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+				return
+			}
+			w.Write([]byte(tc.user))
+		})
+
+		comments := tc.comments
+
+		s.Mux.HandleFunc("/repos/o/r/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" {
+				githubtest.JSON(comments)(w, r)
+				return
+			}
+
+			cm := &github.IssueComment{}
+			if err := json.NewDecoder(r.Body).Decode(cm); err != nil {
+				t.Error(err)
+			}
+			comments = append(comments, cm)
+			githubtest.JSON(cm)(w, r)
+		})
+
+		s.Mux.HandleFunc("/repos/o/r/issues/comments/", func(w http.ResponseWriter, r *http.Request) {
+			id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/repos/o/r/issues/comments/"), 10, 64)
+
+			var edited github.IssueComment
+			if err := json.NewDecoder(r.Body).Decode(&edited); err != nil {
+				t.Error(err)
+			}
+			for _, cm := range comments {
+				if cm.GetID() == id {
+					cm.Body = edited.Body
+				}
+			}
+			githubtest.JSON(&edited)(w, r)
+		})
+
+		var prev string
+
+		err := UpsertComment(s.NewClient(t), "o", "r", 1, "<!-- m -->", func(existing *github.IssueComment) string {
+			prev = existing.GetBody()
+			return "<!-- m -->\nnew"
+		})
+
+		s.Close()
+
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+			continue
+		}
+
+		var bodies []string
+		for _, cm := range comments {
+			bodies = append(bodies, cm.GetBody())
+		}
+
+		if !reflect.DeepEqual(bodies, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected comments: expected=%q, got=%q", i, tc.expected, bodies)
+		}
+
+		if prev != tc.prev {
+			t.Errorf("testcases[%d]: unexpected previous comment: expected=%q, got=%q", i, tc.prev, prev)
+		}
+	}
+}
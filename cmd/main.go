@@ -6,12 +6,17 @@ import (
 	"os"
 
 	"github.com/variantdev/go-actions/cmd/pullnote"
+	"github.com/variantdev/go-actions/cmd/pullsize"
 	"github.com/variantdev/go-actions/cmd/pullvet"
+	"github.com/variantdev/go-actions/pkg/automerge"
 	"github.com/variantdev/go-actions/pkg/cli"
+	"github.com/variantdev/go-actions/pkg/deploy"
 	"github.com/variantdev/go-actions/pkg/exec"
 	"github.com/variantdev/go-actions/pkg/merge"
+	"github.com/variantdev/go-actions/pkg/mergequeue"
 	"github.com/variantdev/go-actions/pkg/rebase"
 	"github.com/variantdev/go-actions/pkg/say"
+	"github.com/variantdev/go-actions/pkg/statusmirror"
 )
 
 func flagUsage() {
@@ -21,10 +26,15 @@ Usage:
   actions [command]
 Available Commands:
   pullvet	checks labels and milestones associated to each pull request for project management and compliance
+  pullsize	computes the size of each pull request and applies exactly one size label like size/M
   exec		runs an arbitrary command and updates GitHub "Check Run" and/or "Status" accordingly.
   merge		merges a PR when it is passing all the required status checks.
+  automerge	merges a PR labeled "automerge" or commented "/merge" by a maintainer once it is approved and passing all the required status checks.
+  mergequeue	merges PRs labeled "queued" one by one, updating each with the base branch and waiting for the required status checks before merging.
   say		adds a comment to an issue or a pull request that triggered the event.
   rebase	rebases the pull request onto the specified branch and force pushes it to the head branch.
+  status-mirror	copies commit statuses and check runs from a commit to another, like from the pull request head before a force-push to the new head.
+  deploy	creates a GitHub "Deployment", runs an arbitrary deploy command and updates the deployment status accordingly.
 
 Use "actions [command] --help" for more information about a command
 `
@@ -43,6 +53,11 @@ const (
 	CmdMerge   = "merge"
 	CmdRebase  = "rebase"
 	CmdSay     = "say"
+	CmdDeploy  = "deploy"
+
+	CmdStatusMirror = "status-mirror"
+	CmdAutomerge    = "automerge"
+	CmdMergeQueue   = "mergequeue"
 )
 
 func main() {
@@ -56,6 +71,7 @@ func main() {
 	subCommands := []cli.Command{
 		pullvet.Command,
 		pullnote.Command,
+		pullsize.Command,
 	}
 
 	for _, subCmd := range subCommands {
@@ -85,6 +101,26 @@ func main() {
 
 		fs.Parse(os.Args[2:])
 
+		if err := cmd.Run(); err != nil {
+			fatal("%v\n", err)
+		}
+	case CmdAutomerge:
+		fs := flag.NewFlagSet(CmdAutomerge, flag.ExitOnError)
+		cmd := automerge.New()
+		cmd.AddFlags(fs)
+
+		fs.Parse(os.Args[2:])
+
+		if err := cmd.Run(); err != nil {
+			fatal("%v\n", err)
+		}
+	case CmdMergeQueue:
+		fs := flag.NewFlagSet(CmdMergeQueue, flag.ExitOnError)
+		cmd := mergequeue.New()
+		cmd.AddFlags(fs)
+
+		fs.Parse(os.Args[2:])
+
 		if err := cmd.Run(); err != nil {
 			fatal("%v\n", err)
 		}
@@ -105,6 +141,26 @@ func main() {
 
 		fs.Parse(os.Args[2:])
 
+		if err := cmd.Run(); err != nil {
+			fatal("%v\n", err)
+		}
+	case CmdDeploy:
+		fs := flag.NewFlagSet(CmdDeploy, flag.ExitOnError)
+		cmd := deploy.New()
+		cmd.AddFlags(fs)
+
+		fs.Parse(os.Args[2:])
+
+		if err := cmd.Run(fs.Args()); err != nil {
+			fatal("%v\n", err)
+		}
+	case CmdStatusMirror:
+		fs := flag.NewFlagSet(CmdStatusMirror, flag.ExitOnError)
+		cmd := statusmirror.New()
+		cmd.AddFlags(fs)
+
+		fs.Parse(os.Args[2:])
+
 		if err := cmd.Run(); err != nil {
 			fatal("%v\n", err)
 		}
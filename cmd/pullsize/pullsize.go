@@ -0,0 +1,39 @@
+package pullsize
+
+import (
+	"flag"
+
+	"github.com/variantdev/go-actions/pkg/cli"
+	"github.com/variantdev/go-actions/pkg/pullsize"
+)
+
+var Command cli.Command = &cmd{}
+
+type cmd struct{}
+
+func (c *cmd) Name() string {
+	return "pullsize"
+}
+
+func (c *cmd) Run(args []string) error {
+	action := pullsize.New()
+
+	usage := `pullsize computes the size of the pull request from the changed lines and files, and applies exactly one size label like size/M.
+It exits with a non-zero status whenever the pull request exceeds either of the hard limits
+`
+
+	if err := cli.Setup(c, args, usage, func(fs *flag.FlagSet) {
+		fs.StringVar(&action.BaseURL, "github-base-url", "", "")
+		fs.StringVar(&action.UploadURL, "github-upload-url", "", "")
+		fs.Var(&action.Excludes, "exclude", "Glob of the paths excluded from the size, like `vendor/`. When provided multiple times, paths matching any of globs are excluded")
+		fs.Var(&action.Weights, "weight", "Weight of the changed lines in the form of `GLOB=FACTOR` like **/*_test.go=0.5. When provided multiple times, the first matching weight applies")
+		fs.StringVar(&action.Thresholds, "thresholds", pullsize.DefaultThresholds, "Minimum weighted lines of each size, in the form of `NAME=MIN_LINES,...`")
+		fs.StringVar(&action.LabelPrefix, "label-prefix", pullsize.DefaultLabelPrefix, "Prefix of the size label. No label is applied when empty")
+		fs.IntVar(&action.MaxLines, "max-lines", 0, "If set, pullsize fails whenever the pull request changes more than N weighted lines")
+		fs.IntVar(&action.MaxFiles, "max-files", 0, "If set, pullsize fails whenever the pull request changes more than N files")
+	}); err != nil {
+		return err
+	}
+
+	return action.Run()
+}
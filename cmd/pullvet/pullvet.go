@@ -35,8 +35,50 @@ when run on GitHub Actions v2
 		fs.Var(&action.MilestoneMatches, "milestone-match", "Regexp pattern to match milestone title against. If set, pullvet tries to find the milestone matches any of patterns and fail if none matched.")
 		fs.Var(&action.NoteTitles, "note", "Require a note with the specified title. pullvet fails whenever the pr misses the note in the pr description. A note can be written in Markdown as: **<title>**:\n```\n<body>\n```")
 		fs.Var(&action.RequireApprovalsBy, "approved-by", "Require approval from user(s). Use GitHub login name like `mumoshu` without `@`")
-		fs.IntVar(&action.MinApprovals, "min-approvals", 0, "Require N approval(s)")
+		fs.Var(&action.ApprovedByTeams, "approved-by-team", "Require N or more approval(s) from members of the team, in the form of `ORG/TEAM[:N]`. N defaults to 1")
+		fs.BoolVar(&action.RequireCodeowners, "require-codeowners", false, "If set, pullvet requires an approval from an owner of every changed path, according to the CODEOWNERS file in the base branch")
+		fs.IntVar(&action.MinApprovals, "min-approvals", 0, "Require N or more approval(s)")
+		fs.BoolVar(&action.IgnoreStaleApprovals, "ignore-stale-approvals", false, "If set, approvals given to commits other than the head of the pull request are ignored")
 		fs.StringVar(&action.NoteRegex, "note-regex", pullvet.DefaultNoteRegex, "Regexp pattern of each note(including the title and the body)")
+		fs.BoolVar(&action.RequireLinkedIssue, "require-linked-issue", false, "If set, pullvet fails whenever the pull request description references no open issue like \"Fixes #123\", or any referenced issue is closed or missing")
+		fs.Var(&action.LinkedIssueLabels, "linked-issue-label", "Label every linked issue must have. When provided multiple times, linked issues must have any of the labels")
+		fs.Var(&action.LinkedIssueMilestoneMatches, "linked-issue-milestone-match", "Regexp pattern to match the milestone title of every linked issue against")
+		fs.Var(&action.TitleMatches, "title-match", "Regexp pattern to match the title of the pull request against. pullvet fails whenever the title matches none of patterns")
+		fs.BoolVar(&action.ConventionalTitle, "conventional-title", false, "If set, pullvet fails whenever the title of the pull request doesn't follow Conventional Commits, like \"feat(api)!: drop v1 endpoints\"")
+		fs.BoolVar(&action.ConventionalCommits, "conventional-commits", false, "If set, pullvet fails whenever any commit message doesn't follow Conventional Commits")
+		fs.Var(&action.ConventionalTypes, "conventional-type", "Type allowed in Conventional Commits, like `feat`. When provided multiple times, any of the types is allowed. Any type is allowed when not provided")
+		fs.IntVar(&action.MaxSubjectLength, "max-subject-length", 0, "If set, pullvet fails whenever the subject of any commit message is longer than N characters")
+		fs.Var(&action.CommitMatches, "commit-match", "Regexp pattern to match every commit message against. pullvet fails whenever any commit message matches none of patterns")
+		fs.StringVar(&action.RequireIssueKey, "require-issue-key", "", "Regexp pattern of the issue key like `[A-Z]+-[0-9]+`. pullvet fails whenever any commit message misses the issue key")
+		fs.BoolVar(&action.RequireSignoff, "require-signoff", false, "If set, pullvet fails whenever any commit message misses the Signed-off-by trailer with the email address of the commit author")
+		fs.Var(&action.ExactlyOneLabelMatches, "exactly-one-label-match", "Regexp pattern like `kind/.+`. pullvet fails whenever no label or more than one label matches the pattern")
+		fs.Var(&action.AtMostOneLabelMatches, "at-most-one-label-match", "Regexp pattern like `size/.+`. pullvet fails whenever more than one label matches the pattern")
+		fs.Var(&action.ForbiddenLabelMatches, "forbid-label-match", "Regexp pattern like `do-not-merge/.+`. pullvet fails whenever any label matches the pattern, regardless of -require-any and -require-all")
+		fs.Var(&action.LabelRequires, "label-requires", "Rule in the form of `LABEL=EXPR` like breaking-change=note(\"releasenote\"), that must hold only when the pull request has the label")
+		fs.Var(&action.RequireChecked, "require-checked", "Text of the task list item like `Tests added` in the pull request description. pullvet fails whenever the item is unchecked or missing")
+		fs.Var(&action.RequireAllCheckedInSections, "require-all-checked-in-section", "Title of the heading like `Checklist` in the pull request description. pullvet fails whenever any task list item under the heading is unchecked, or the heading is missing")
+		fs.Var(&action.SkipIf, "skip-if", "Expression like `draft()`. pullvet skips evaluating the pull request and succeeds whenever the expression holds")
+		fs.Var(&action.SkipAuthors, "skip-author", "Login of the author like `dependabot[bot]`, whose pull requests pullvet skips evaluating")
+		fs.BoolVar(&action.SkipBots, "skip-bots", false, "If set, pullvet skips evaluating pull requests opened by bots")
+		fs.DurationVar(&action.MinOpen, "min-open", 0, "pullvet fails until the pull request has been open for the duration like 24h")
+		fs.BoolVar(&action.MinOpenSincePush, "min-open-since-push", false, "If set, -min-open is measured from the last push instead of the creation of the pull request")
+		fs.StringVar(&action.FreezeFile, "freeze-file", "", "Path to the file declaring freeze windows like `.github/freeze.yaml`. pullvet fails during the windows unless the pull request has the exception label. Read from the same source as -config")
+		fs.Var(&action.RuleFlags, "rule", "Rule in the form of `NAME=EXPR` like sized=(label_match(\"size/.+\") && milestone_match(\"v.+\")) || label(\"hotfix\"). Every rule must hold regardless of -require-any and -require-all")
+		fs.StringVar(&action.NoteSchemaFile, "note-schema", "", "Path to the note schema file declaring allowed notes and their bodies, like `.github/notes.yaml`. Read from the same source as -config")
+		fs.BoolVar(&action.Fix, "fix", false, "If set, pullvet fixes the pull request when requirements or rules with fixes failed, posts a comment explaining each change, and re-evaluates the pull request")
+		fs.StringVar(&action.FixLabel, "fix-label", "", "Label added in the -fix mode when none of labels given via -label is present")
+		fs.BoolVar(&action.FixMilestone, "fix-milestone", false, "If set, pullvet assigns the open milestone matching -milestone-match with the nearest due date in the -fix mode")
+		fs.Var(&action.FixReviewers, "fix-reviewer", "User or team in the form of `ORG/TEAM` to request reviews from in the -fix mode, when approval requirements failed")
+		fs.StringVar(&action.Output, "output", pullvet.OutputText, "Format of the report. Either \"text\", \"json\" or \"markdown\", or \"csv\" with -all-open")
+		fs.StringVar(&action.CheckRunName, "check-run-name", "", "If set, pullvet publishes the report as the check run with the name for the head of the pull request")
+		fs.StringVar(&action.StatusContext, "status-context", "", "If set, pullvet sets the commit status with the context for the head of the pull request")
+		fs.BoolVar(&action.AllOpen, "all-open", false, "If set, pullvet evaluates every open pull request instead of the pull request from the event, and outputs the consolidated report")
+		fs.StringVar(&action.Base, "base", "", "Base branch of the pull requests to evaluate with -all-open. Every open pull request is evaluated when empty")
+		fs.Var(&action.Repos, "repo", "Repository in the form of `OWNER/REPO` to evaluate with -all-open. Defaults to the repository of the workflow")
+		fs.StringVar(&action.ConfigFile, "config", "", "Path to the config file declaring rule sets, like `.github/pullvet.yaml`")
+		fs.StringVar(&action.ConfigSource, "config-source", pullvet.ConfigSourceBase, "Where to read the config file from. Either \"base\" for the base branch of the pull request, or \"worktree\" for the working tree")
+		fs.Var(&action.WhenChangedFlags, "when-changed", "Rule in the form of `GLOB=EXPR` like deploy/**=label(\"ops-approved\"), that must hold only when any file matching GLOB is changed in the pull request")
+		fs.Var(&action.RuleMessageFlags, "rule-message", "Message shown when the rule failed, in the form of `NAME=MESSAGE`")
 	}); err != nil {
 		return err
 	}
@@ -2,10 +2,12 @@ package actions
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/google/go-github/v28/github"
 	"io/ioutil"
 	"os"
+	"strings"
 )
 
 func EventPath() string {
@@ -28,6 +30,17 @@ func EventName() string {
 	return name
 }
 
+// OwnerRepo returns the owner and the name of the repository that triggered the workflow
+func OwnerRepo() (string, string, error) {
+	// See https://help.github.com/en/articles/virtual-environments-for-github-actions#default-environment-variables
+	ownerRepo := os.Getenv("GITHUB_REPOSITORY")
+	parts := strings.Split(ownerRepo, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected format of GITHUB_REPOSITORY %q: expected OWNER/REPO", ownerRepo)
+	}
+	return parts[0], parts[1], nil
+}
+
 func Event() []byte {
 	payload, err := ioutil.ReadFile(EventPath())
 	if err != nil {
@@ -48,6 +61,23 @@ func PullRequestEvent() (*github.PullRequestEvent, error) {
 	return evt.(*github.PullRequestEvent), nil
 }
 
+// PullRequestBeforeSHA returns the SHA of the pull request head before the push that triggered
+// the `pull_request` event with the `synchronize` action. It returns an empty string for other events.
+func PullRequestBeforeSHA() string {
+	if EventName() != "pull_request" {
+		return ""
+	}
+
+	// go-github's PullRequestEvent lacks the `before` field
+	var evt struct {
+		Before string `json:"before"`
+	}
+	if err := json.Unmarshal(Event(), &evt); err != nil {
+		return ""
+	}
+	return evt.Before
+}
+
 func CheckRunEvent() (*github.CheckRunEvent, error) {
 	evt, err := github.ParseWebHook("check_run", Event())
 	if err != nil {
@@ -64,6 +94,30 @@ func CheckSuiteEvent() (*github.CheckSuiteEvent, error) {
 	return evt.(*github.CheckSuiteEvent), nil
 }
 
+func PullRequestReviewEvent() (*github.PullRequestReviewEvent, error) {
+	evt, err := github.ParseWebHook("pull_request_review", Event())
+	if err != nil {
+		return nil, err
+	}
+	return evt.(*github.PullRequestReviewEvent), nil
+}
+
+func IssueCommentEvent() (*github.IssueCommentEvent, error) {
+	evt, err := github.ParseWebHook("issue_comment", Event())
+	if err != nil {
+		return nil, err
+	}
+	return evt.(*github.IssueCommentEvent), nil
+}
+
+func StatusEvent() (*github.StatusEvent, error) {
+	evt, err := github.ParseWebHook("status", Event())
+	if err != nil {
+		return nil, err
+	}
+	return evt.(*github.StatusEvent), nil
+}
+
 func IssueEvent() (*github.IssuesEvent, error) {
 	evt, err := github.ParseWebHook("issues", Event())
 	if err != nil {
@@ -141,6 +195,14 @@ func PullRequest() (*github.PullRequest, string, string, error) {
 		pr = pull.PullRequest
 		owner = pull.Repo.Owner.GetLogin()
 		repo = pull.Repo.GetName()
+	case "pull_request_review":
+		review, err := PullRequestReviewEvent()
+		if err != nil {
+			return nil, "", "", err
+		}
+		pr = review.PullRequest
+		owner = review.Repo.Owner.GetLogin()
+		repo = review.Repo.GetName()
 	case "check_run":
 		checkRun, err := CheckRunEvent()
 		if err != nil {
@@ -159,7 +221,7 @@ func PullRequest() (*github.PullRequest, string, string, error) {
 		owner = checkSuite.Repo.Owner.GetLogin()
 		repo = checkSuite.Repo.GetName()
 	default:
-		return nil, "", "", fmt.Errorf("unhandled event name %q. expected one of: issues, pull_request, check_run, check_suite", evtName)
+		return nil, "", "", fmt.Errorf("unhandled event name %q. expected one of: issues, pull_request, pull_request_review, check_run, check_suite", evtName)
 	}
 	return pr, owner, repo, nil
 }
@@ -5,4 +5,5 @@ go 1.12
 require (
 	github.com/google/go-github/v28 v28.1.1
 	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
+	gopkg.in/yaml.v2 v2.2.4
 )
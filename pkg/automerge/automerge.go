@@ -0,0 +1,431 @@
+package automerge
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+	"github.com/variantdev/go-actions/pkg/merge"
+)
+
+const (
+	DefaultLabel   = "automerge"
+	DefaultCommand = "/merge"
+)
+
+// commentMarker identifies the comment that the action keeps updating in the pull request conversation
+const commentMarker = "<!-- go-actions/automerge -->"
+
+// notification is when handle comments the blockers
+type notification int
+
+const (
+	notifyNever notification = iota
+	// notifyFailingChecks comments only when required checks failed, as other checks complete often without changing the blockers
+	notifyFailingChecks
+	notifyAlways
+)
+
+// Methods are the merge methods accepted in labels and commands
+var Methods = []string{"merge", "squash", "rebase"}
+
+// DefaultMaintainers are the author associations allowed to use the command by default
+var DefaultMaintainers = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+type Action struct {
+	BaseURL, UploadURL string
+
+	// Label enables auto-merge with Method. Label followed by `/` and a method like `automerge/squash` enables auto-merge with the method
+	Label string
+	// Command is the comment command like `/merge [METHOD]` that merges the pull request, or enables auto-merge until it can be merged
+	Command string
+	// Method is the merge method used when the label or the command specifies none
+	Method string
+	// MinApprovals is the number of approvals required to merge
+	MinApprovals int
+	// Maintainers are the author associations like MEMBER allowed to use the command
+	Maintainers actions.StringSlice
+}
+
+func New() *Action {
+	return &Action{}
+}
+
+func (c *Action) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.BaseURL, "github-base-url", "", "")
+	fs.StringVar(&c.UploadURL, "github-upload-url", "", "")
+	fs.StringVar(&c.Label, "label", DefaultLabel, "Label that enables auto-merge. Append a method to the label like automerge/squash to override -method")
+	fs.StringVar(&c.Command, "command", DefaultCommand, "Comment command that merges the pull request as soon as it can be merged, optionally followed by a method like /merge squash")
+	fs.StringVar(&c.Method, "method", "merge", `The merge method used when the label or the command specifies none. Either "merge", "squash" or "rebase"`)
+	fs.IntVar(&c.MinApprovals, "min-approvals", 1, "Number of approvals required to merge")
+	fs.Var(&c.Maintainers, "maintainer", "Author association like `MEMBER` allowed to use the command. Defaults to OWNER, MEMBER and COLLABORATOR")
+}
+
+func (c *Action) Run() error {
+	client, err := c.getClient()
+	if err != nil {
+		return err
+	}
+
+	owner, repo, err := actions.OwnerRepo()
+	if err != nil {
+		return err
+	}
+
+	switch name := actions.EventName(); name {
+	case "pull_request":
+		evt, err := actions.PullRequestEvent()
+		if err != nil {
+			return err
+		}
+
+		switch evt.GetAction() {
+		case "synchronize":
+			return c.disarm(client, owner, repo, evt.PullRequest)
+		case "labeled":
+			if _, ok := c.methodFromLabels([]string{evt.Label.GetName()}); ok {
+				return c.handle(client, owner, repo, evt.PullRequest.GetNumber(), "", notifyAlways)
+			}
+		}
+
+		return c.handle(client, owner, repo, evt.PullRequest.GetNumber(), "", notifyNever)
+	case "pull_request_review":
+		evt, err := actions.PullRequestReviewEvent()
+		if err != nil {
+			return err
+		}
+
+		return c.handle(client, owner, repo, evt.PullRequest.GetNumber(), "", notifyNever)
+	case "check_suite":
+		evt, err := actions.CheckSuiteEvent()
+		if err != nil {
+			return err
+		}
+
+		if evt.GetAction() != "completed" {
+			return nil
+		}
+
+		for _, pr := range evt.CheckSuite.PullRequests {
+			if err := c.handle(client, owner, repo, pr.GetNumber(), "", notifyFailingChecks); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case "status":
+		evt, err := actions.StatusEvent()
+		if err != nil {
+			return err
+		}
+
+		pulls, err := actions.ListOpenPullRequests(client, owner, repo, "")
+		if err != nil {
+			return err
+		}
+
+		for _, pr := range pulls {
+			if pr.GetHead().GetSHA() != evt.GetSHA() {
+				continue
+			}
+			if err := c.handle(client, owner, repo, pr.GetNumber(), "", notifyFailingChecks); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case "issue_comment":
+		evt, err := actions.IssueCommentEvent()
+		if err != nil {
+			return err
+		}
+
+		return c.handleComment(client, owner, repo, evt)
+	default:
+		return fmt.Errorf("unhandled event name %q. expected one of: pull_request, pull_request_review, check_suite, status, issue_comment", name)
+	}
+}
+
+// methodFromLabels returns the merge method of the first auto-merge label, and false when none of labels enables auto-merge
+func (c *Action) methodFromLabels(labels []string) (string, bool) {
+	for _, l := range labels {
+		if l == c.label() {
+			return c.method(), true
+		}
+		if strings.HasPrefix(l, c.label()+"/") {
+			if m := strings.TrimPrefix(l, c.label()+"/"); isMethod(m) {
+				return m, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ParseCommand returns the method given to the command in the comment, and false when the comment is not the command.
+// The method is empty when the command has no argument.
+func ParseCommand(command, body string) (string, bool, error) {
+	line := strings.TrimSpace(strings.SplitN(strings.Replace(body, "\r\n", "\n", -1), "\n", 2)[0])
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != command {
+		return "", false, nil
+	}
+
+	switch len(fields) {
+	case 1:
+		return "", true, nil
+	case 2:
+		if !isMethod(fields[1]) {
+			return "", true, fmt.Errorf("unsupported merge method %q: expected any of %s", fields[1], strings.Join(Methods, ", "))
+		}
+		return fields[1], true, nil
+	default:
+		return "", true, fmt.Errorf("unexpected arguments %q: expected %s [%s]", strings.Join(fields[1:], " "), command, strings.Join(Methods, "|"))
+	}
+}
+
+func isMethod(m string) bool {
+	for _, method := range Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Blockers returns the reasons the pull request can't be merged. Pending checks are not blockers, as they may pass later.
+// Only approvals of the head commit count, so that commits pushed after the approvals are never merged unreviewed.
+func Blockers(pr *github.PullRequest, reviews []*github.PullRequestReview, checks *merge.Checks, minApprovals int) []string {
+	var blockers []string
+
+	if pr.GetDraft() {
+		blockers = append(blockers, "the pull request is a draft")
+	}
+
+	if pr.Mergeable != nil && !pr.GetMergeable() {
+		blockers = append(blockers, "the pull request has conflicts with the base branch")
+	}
+
+	latest := actions.LatestReviews(reviews)
+
+	var logins []string
+	for login := range latest {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+
+	var approvals int
+	var stale, changesRequested []string
+	for _, login := range logins {
+		r := latest[login]
+		switch r.GetState() {
+		case "APPROVED":
+			// Approvals of earlier commits don't cover the commits pushed since
+			if r.GetCommitID() != pr.GetHead().GetSHA() {
+				stale = append(stale, login)
+				continue
+			}
+			approvals++
+		case "CHANGES_REQUESTED":
+			changesRequested = append(changesRequested, login)
+		}
+	}
+
+	if approvals < minApprovals {
+		blocker := fmt.Sprintf("not enough approvals: expected at least %d, got %d", minApprovals, approvals)
+		if len(stale) > 0 {
+			blocker += fmt.Sprintf(". Ignored approvals of earlier commits by %s", strings.Join(stale, ", "))
+		}
+		blockers = append(blockers, blocker)
+	}
+
+	if len(changesRequested) > 0 {
+		blockers = append(blockers, fmt.Sprintf("changes requested by %s", strings.Join(changesRequested, ", ")))
+	}
+
+	if checks != nil && len(checks.Failing) > 0 {
+		blockers = append(blockers, fmt.Sprintf("required checks failed: %s", strings.Join(checks.Failing, ", ")))
+	}
+
+	return blockers
+}
+
+// handle merges the pull request when it has the auto-merge label, or when method is given by the command, and nothing blocks it.
+// It comments the blockers as notify specifies.
+func (c *Action) handle(client *github.Client, owner, repo string, num int, method string, notify notification) error {
+	pr, _, err := client.PullRequests.Get(context.Background(), owner, repo, num)
+	if err != nil {
+		return err
+	}
+
+	if pr.GetState() != "open" {
+		log.Printf("Skipped pull request #%d: it is %s", num, pr.GetState())
+		return nil
+	}
+
+	if method == "" {
+		var labels []string
+		for _, l := range pr.Labels {
+			labels = append(labels, l.GetName())
+		}
+
+		var ok bool
+		method, ok = c.methodFromLabels(labels)
+		if !ok {
+			log.Printf("Skipped pull request #%d: auto-merge is not enabled", num)
+			return nil
+		}
+	}
+
+	reviews, err := actions.ListPullRequestReviews(client, owner, repo, num)
+	if err != nil {
+		return err
+	}
+
+	checks, err := merge.GetChecks(client, owner, repo, pr.GetBase().GetRef(), pr.GetHead().GetSHA())
+	if err != nil {
+		return err
+	}
+
+	if blockers := Blockers(pr, reviews, checks, c.MinApprovals); len(blockers) > 0 {
+		log.Printf("Unable to merge pull request #%d: %s", num, strings.Join(blockers, "; "))
+
+		if notify == notifyAlways || (notify == notifyFailingChecks && len(checks.Failing) > 0) {
+			return c.comment(client, owner, repo, num, fmt.Sprintf("Unable to merge automatically:\n\n* %s\n", strings.Join(blockers, "\n* ")))
+		}
+
+		return nil
+	}
+
+	if !checks.Completed() {
+		log.Printf("Waiting for required checks of pull request #%d: %s", num, checks)
+		return nil
+	}
+
+	if pr.Mergeable == nil {
+		log.Printf("Waiting for GitHub to compute the mergeability of pull request #%d", num)
+		return nil
+	}
+
+	m := merge.New()
+	m.BaseURL = c.BaseURL
+	m.UploadURL = c.UploadURL
+	m.Method = method
+
+	return m.MergeIfNecessary(&merge.Target{Owner: owner, Repo: repo, PullRequest: pr})
+}
+
+// handleComment merges the pull request on the command from a maintainer.
+// When the pull request can't be merged yet, it adds the auto-merge label so that it is merged once it can be.
+func (c *Action) handleComment(client *github.Client, owner, repo string, evt *github.IssueCommentEvent) error {
+	if evt.GetAction() != "created" || !evt.Issue.IsPullRequest() {
+		return nil
+	}
+
+	num := evt.Issue.GetNumber()
+
+	method, ok, err := ParseCommand(c.command(), evt.Comment.GetBody())
+	if !ok {
+		return nil
+	}
+	if err != nil {
+		return c.comment(client, owner, repo, num, fmt.Sprintf("Unable to merge automatically: %v", err))
+	}
+
+	assoc := evt.Comment.GetAuthorAssociation()
+	if !containsFold(c.maintainers(), assoc) {
+		return c.comment(client, owner, repo, num, fmt.Sprintf("Unable to merge automatically: only %s can use %s", strings.Join(c.maintainers(), ", "), c.command()))
+	}
+
+	label := c.label()
+	if method == "" {
+		method = c.method()
+	} else if method != c.method() {
+		label += "/" + method
+	}
+
+	if _, _, err := client.Issues.AddLabelsToIssue(context.Background(), owner, repo, num, []string{label}); err != nil {
+		return err
+	}
+
+	return c.handle(client, owner, repo, num, method, notifyAlways)
+}
+
+// disarm removes the auto-merge labels when new commits were pushed, so that unreviewed commits are never merged automatically
+func (c *Action) disarm(client *github.Client, owner, repo string, pr *github.PullRequest) error {
+	num := pr.GetNumber()
+
+	var removed []string
+	for _, l := range pr.Labels {
+		if _, ok := c.methodFromLabels([]string{l.GetName()}); !ok {
+			continue
+		}
+
+		if err := actions.RemoveLabel(client, owner, repo, num, l.GetName()); err != nil {
+			return err
+		}
+
+		removed = append(removed, l.GetName())
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	return c.comment(client, owner, repo, num, fmt.Sprintf("Removed the label `%s`, as new commits were pushed. Add it again to merge the new commits automatically.", strings.Join(removed, "`, `")))
+}
+
+// comment posts body to the pull request conversation, or edits the comment posted by a previous run,
+// so that the conversation isn't flooded by a comment per event
+func (c *Action) comment(client *github.Client, owner, repo string, num int, body string) error {
+	return actions.UpsertComment(client, owner, repo, num, commentMarker, func(*github.IssueComment) string {
+		return commentMarker + "\n" + body
+	})
+}
+
+func (c *Action) label() string {
+	if c.Label == "" {
+		return DefaultLabel
+	}
+	return c.Label
+}
+
+func (c *Action) command() string {
+	if c.Command == "" {
+		return DefaultCommand
+	}
+	return c.Command
+}
+
+func (c *Action) method() string {
+	if c.Method == "" {
+		return "merge"
+	}
+	return c.Method
+}
+
+func (c *Action) maintainers() []string {
+	if len(c.Maintainers) == 0 {
+		return DefaultMaintainers
+	}
+	return c.Maintainers
+}
+
+func containsFold(items []string, s string) bool {
+	for _, i := range items {
+		if strings.EqualFold(i, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Action) getClient() (*github.Client, error) {
+	return actions.CreateClient(os.Getenv("GITHUB_TOKEN"), c.BaseURL, c.UploadURL)
+}
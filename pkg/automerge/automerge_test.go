@@ -0,0 +1,315 @@
+package automerge
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions/pkg/githubtest"
+	"github.com/variantdev/go-actions/pkg/merge"
+)
+
+func TestParseCommand(t *testing.T) {
+	testcases := []struct {
+		body   string
+		method string
+		ok     bool
+		err    string
+	}{
+		{body: "LGTM"},
+		{body: "/merge", ok: true},
+		{body: " /merge squash \r\nThanks!", method: "squash", ok: true},
+		{body: "/merged"},
+		{body: "/merge fast-forward", ok: true, err: `unsupported merge method "fast-forward": expected any of merge, squash, rebase`},
+		{body: "/merge squash now", ok: true, err: `unexpected arguments "squash now": expected /merge [merge|squash|rebase]`},
+	}
+
+	for i, tc := range testcases {
+		method, ok, err := ParseCommand(DefaultCommand, tc.body)
+
+		if method != tc.method || ok != tc.ok {
+			t.Errorf("testcases[%d]: unexpected result: expected=(%q, %v), got=(%q, %v)", i, tc.method, tc.ok, method, ok)
+		}
+
+		if (tc.err == "" && err != nil) || (tc.err != "" && (err == nil || err.Error() != tc.err)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, tc.err, err)
+		}
+	}
+}
+
+func TestMethodFromLabels(t *testing.T) {
+	cmd := &Action{Method: "squash"}
+
+	testcases := []struct {
+		labels []string
+		method string
+		ok     bool
+	}{
+		{labels: []string{"bug"}},
+		{labels: []string{"bug", "automerge"}, method: "squash", ok: true},
+		{labels: []string{"automerge/rebase"}, method: "rebase", ok: true},
+		{labels: []string{"automerge/later"}},
+	}
+
+	for i, tc := range testcases {
+		method, ok := cmd.methodFromLabels(tc.labels)
+		if method != tc.method || ok != tc.ok {
+			t.Errorf("testcases[%d]: unexpected result: expected=(%q, %v), got=(%q, %v)", i, tc.method, tc.ok, method, ok)
+		}
+	}
+}
+
+func TestBlockers(t *testing.T) {
+	at := func(minutes int) *time.Time {
+		t := time.Date(2019, 10, 15, 12, minutes, 0, 0, time.UTC)
+		return &t
+	}
+
+	review := func(login, state, sha string, submitted *time.Time) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: github.String(login)}, State: github.String(state), CommitID: github.String(sha), SubmittedAt: submitted}
+	}
+
+	head := &github.PullRequestBranch{SHA: github.String("head")}
+
+	testcases := []struct {
+		pr           *github.PullRequest
+		reviews      []*github.PullRequestReview
+		checks       *merge.Checks
+		minApprovals int
+		expected     []string
+	}{
+		{
+			pr:           &github.PullRequest{Head: head, Mergeable: github.Bool(true)},
+			reviews:      []*github.PullRequestReview{review("a", "APPROVED", "head", at(0)), review("b", "COMMENTED", "head", at(1))},
+			checks:       &merge.Checks{Required: []string{"ci"}, Pending: []string{"ci"}},
+			minApprovals: 1,
+		},
+		{
+			pr: &github.PullRequest{Head: head, Draft: github.Bool(true), Mergeable: github.Bool(false)},
+			reviews: []*github.PullRequestReview{
+				review("a", "APPROVED", "head", at(0)),
+				review("a", "CHANGES_REQUESTED", "head", at(1)),
+				review("b", "CHANGES_REQUESTED", "head", at(2)),
+				review("b", "APPROVED", "head", at(3)),
+			},
+			checks:       &merge.Checks{Required: []string{"ci", "lint"}, Failing: []string{"ci", "lint"}},
+			minApprovals: 2,
+			expected: []string{
+				"the pull request is a draft",
+				"the pull request has conflicts with the base branch",
+				"not enough approvals: expected at least 2, got 1",
+				"changes requested by a",
+				"required checks failed: ci, lint",
+			},
+		},
+		{
+			// Approvals given before the latest push are stale
+			pr:           &github.PullRequest{Head: head, Mergeable: github.Bool(true)},
+			reviews:      []*github.PullRequestReview{review("a", "APPROVED", "before", at(0)), review("b", "APPROVED", "head", at(1))},
+			minApprovals: 2,
+			expected: []string{
+				"not enough approvals: expected at least 2, got 1. Ignored approvals of earlier commits by a",
+			},
+		},
+	}
+
+	for i, tc := range testcases {
+		actual := Blockers(tc.pr, tc.reviews, tc.checks, tc.minApprovals)
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected blockers: expected=%v, got=%v", i, tc.expected, actual)
+		}
+	}
+}
+
+// conversation is a fake pull request conversation served at /repos/o/r/issues/1/comments, where the action comments as "bot"
+type conversation struct {
+	comments []*github.IssueComment
+	edits    int
+}
+
+func (cv *conversation) register(t *testing.T, s *githubtest.Server) {
+	s.Mux.HandleFunc("/user", githubtest.JSON(&github.User{Login: github.String("bot")}))
+
+	s.Mux.HandleFunc("/repos/o/r/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			githubtest.JSON(cv.comments)(w, r)
+			return
+		}
+
+		cm := &github.IssueComment{}
+		if err := json.NewDecoder(r.Body).Decode(cm); err != nil {
+			t.Error(err)
+		}
+		cm.ID = github.Int64(int64(len(cv.comments) + 1))
+		cm.User = &github.User{Login: github.String("bot")}
+		cv.comments = append(cv.comments, cm)
+		githubtest.JSON(cm)(w, r)
+	})
+
+	s.Mux.HandleFunc("/repos/o/r/issues/comments/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" {
+			t.Errorf("unexpected method: %s", r.Method)
+		}
+
+		id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/repos/o/r/issues/comments/"), 10, 64)
+
+		var edited github.IssueComment
+		if err := json.NewDecoder(r.Body).Decode(&edited); err != nil {
+			t.Error(err)
+		}
+		for _, cm := range cv.comments {
+			if cm.GetID() == id {
+				cm.Body = edited.Body
+			}
+		}
+		cv.edits++
+		githubtest.JSON(&edited)(w, r)
+	})
+}
+
+func (cv *conversation) bodies() []string {
+	bodies := []string{}
+	for _, cm := range cv.comments {
+		bodies = append(bodies, cm.GetBody())
+	}
+	return bodies
+}
+
+func TestComment(t *testing.T) {
+	s := githubtest.NewServer()
+	defer s.Close()
+
+	// Comments of other users are never edited, even with the marker
+	cv := &conversation{comments: []*github.IssueComment{
+		{ID: github.Int64(1), User: &github.User{Login: github.String("alice")}, Body: github.String("LGTM")},
+		{ID: github.Int64(2), User: &github.User{Login: github.String("mallory")}, Body: github.String(commentMarker)},
+	}}
+	cv.register(t, s)
+
+	cmd := &Action{}
+	client := s.NewClient(t)
+
+	for _, body := range []string{"first", "second", "second"} {
+		if err := cmd.comment(client, "o", "r", 1, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if expected := []string{"LGTM", commentMarker, commentMarker + "\nsecond"}; !reflect.DeepEqual(cv.bodies(), expected) {
+		t.Errorf("unexpected comments: expected=%v, got=%v", expected, cv.bodies())
+	}
+
+	// The same body is never posted twice
+	if cv.edits != 1 {
+		t.Errorf("unexpected number of edits: expected=1, got=%d", cv.edits)
+	}
+}
+
+func TestHandleNotify(t *testing.T) {
+	testcases := []struct {
+		notify   notification
+		state    string
+		expected []string
+	}{
+		{
+			notify:   notifyNever,
+			state:    "failure",
+			expected: []string{},
+		},
+		{
+			// Blockers other than failed checks don't change on completion of checks
+			notify:   notifyFailingChecks,
+			state:    "pending",
+			expected: []string{},
+		},
+		{
+			notify:   notifyFailingChecks,
+			state:    "failure",
+			expected: []string{commentMarker + "\nUnable to merge automatically:\n\n* not enough approvals: expected at least 1, got 0\n* required checks failed: ci\n"},
+		},
+		{
+			notify:   notifyAlways,
+			state:    "pending",
+			expected: []string{commentMarker + "\nUnable to merge automatically:\n\n* not enough approvals: expected at least 1, got 0\n"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		s := githubtest.NewServer()
+
+		cv := &conversation{}
+		cv.register(t, s)
+
+		s.Mux.HandleFunc("/repos/o/r/pulls/1", githubtest.JSON(&github.PullRequest{
+			Number: github.Int(1),
+			State:  github.String("open"),
+			Labels: []*github.Label{{Name: github.String("automerge")}},
+			Base:   &github.PullRequestBranch{Ref: github.String("master")},
+			Head:   &github.PullRequestBranch{SHA: github.String("abcdef")},
+		}))
+		s.Mux.HandleFunc("/repos/o/r/pulls/1/reviews", githubtest.JSON([]*github.PullRequestReview{}))
+		s.Mux.HandleFunc("/repos/o/r/branches/master/protection/required_status_checks", githubtest.JSON(&github.RequiredStatusChecks{Contexts: []string{"ci"}}))
+		s.Mux.HandleFunc("/repos/o/r/commits/abcdef/status", githubtest.JSON(&github.CombinedStatus{
+			Statuses: []github.RepoStatus{{Context: github.String("ci"), State: github.String(tc.state)}},
+		}))
+		s.Mux.HandleFunc("/repos/o/r/commits/abcdef/check-runs", githubtest.JSON(&github.ListCheckRunsResults{}))
+
+		cmd := &Action{MinApprovals: 1}
+		err := cmd.handle(s.NewClient(t), "o", "r", 1, "", tc.notify)
+
+		s.Close()
+
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(cv.bodies(), tc.expected) {
+			t.Errorf("testcases[%d]: unexpected comments: expected=%q, got=%q", i, tc.expected, cv.bodies())
+		}
+	}
+}
+
+func TestDisarm(t *testing.T) {
+	s := githubtest.NewServer()
+	defer s.Close()
+
+	var removed []string
+
+	cv := &conversation{}
+	cv.register(t, s)
+
+	s.Mux.HandleFunc("/repos/o/r/issues/1/labels/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Errorf("unexpected method: %s", r.Method)
+		}
+		// The label must be a single path segment
+		removed = append(removed, strings.TrimPrefix(r.URL.EscapedPath(), "/repos/o/r/issues/1/labels/"))
+		githubtest.JSON([]*github.Label{})(w, r)
+	})
+
+	pr := &github.PullRequest{
+		Number: github.Int(1),
+		Labels: []*github.Label{{Name: github.String("bug")}, {Name: github.String("automerge")}, {Name: github.String("automerge/squash")}},
+	}
+
+	cmd := &Action{}
+	if err := cmd.disarm(s.NewClient(t), "o", "r", pr); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"automerge", "automerge%2Fsquash"}; !reflect.DeepEqual(removed, expected) {
+		t.Errorf("unexpected removed labels: expected=%v, got=%v", expected, removed)
+	}
+
+	if expected := []string{commentMarker + "\nRemoved the label `automerge`, `automerge/squash`, as new commits were pushed. Add it again to merge the new commits automatically."}; !reflect.DeepEqual(cv.bodies(), expected) {
+		t.Errorf("unexpected comments: expected=%v, got=%v", expected, cv.bodies())
+	}
+}
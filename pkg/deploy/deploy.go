@@ -0,0 +1,261 @@
+package deploy
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+)
+
+type Action struct {
+	BaseURL, UploadURL string
+
+	Ref         string
+	Environment string
+	Task        string
+	Payload     string
+	Description string
+
+	RequiredContexts actions.StringSlice
+
+	Transient  bool
+	Production bool
+
+	EnvironmentURL string
+	LogURL         string
+
+	InactivatePrevious bool
+
+	Cmd  string
+	Args []string
+}
+
+type Target struct {
+	Owner, Repo string
+	// Ref is the branch, tag or SHA to be deployed
+	Ref string
+}
+
+func New() *Action {
+	return &Action{
+		BaseURL:   "",
+		UploadURL: "",
+	}
+}
+
+func (c *Action) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.BaseURL, "github-base-url", "", "")
+	fs.StringVar(&c.UploadURL, "github-upload-url", "", "")
+	fs.StringVar(&c.Ref, "ref", "", "The branch, tag or SHA to deploy. Defaults to the head SHA of the pull request that triggered the event")
+	fs.StringVar(&c.Environment, "environment", "production", "Name of the environment to deploy to, like `production`, `staging` or `pr-123`")
+	fs.StringVar(&c.Task, "task", "deploy", "The deployment task")
+	fs.StringVar(&c.Payload, "payload", "", "JSON payload with extra information about the deployment")
+	fs.StringVar(&c.Description, "description", "", "Short description of the deployment")
+	fs.Var(&c.RequiredContexts, "required-context", "Status context that must be passing before the deployment is created. Specify multiple times to require two or more contexts. By default, no context is verified")
+	fs.BoolVar(&c.Transient, "transient", false, "Marks the environment as transient, like a preview environment that is destroyed later")
+	fs.BoolVar(&c.Production, "production", false, "Marks the environment as the one that end-users directly interact with")
+	fs.StringVar(&c.EnvironmentURL, "environment-url", "", "URL for accessing the deployed environment")
+	fs.StringVar(&c.LogURL, "log-url", "", "URL for the deployment output")
+	fs.BoolVar(&c.InactivatePrevious, "inactivate-previous", false, "Marks the previous deployments in the same environment as inactive when the deployment succeeded")
+}
+
+func (c *Action) Run(args []string) error {
+	numArgs := len(args)
+	if numArgs > 0 {
+		c.Cmd = args[0]
+	}
+	if numArgs > 1 {
+		c.Args = args[1:]
+	}
+
+	if c.Cmd == "" {
+		return fmt.Errorf("missing command to deploy. Run it like: actions deploy -environment staging -- ./deploy.sh")
+	}
+
+	var target *Target
+
+	if c.Ref != "" {
+		owner, repo, err := actions.OwnerRepo()
+		if err != nil {
+			return err
+		}
+		target = &Target{
+			Owner: owner,
+			Repo:  repo,
+			Ref:   c.Ref,
+		}
+	} else {
+		pr, owner, repo, err := actions.PullRequest()
+		if err != nil {
+			return err
+		}
+		target = &Target{
+			Owner: owner,
+			Repo:  repo,
+			Ref:   pr.Head.GetSHA(),
+		}
+	}
+
+	return c.Deploy(target)
+}
+
+// Deploy creates a deployment for the target, runs the command and updates the deployment status according to the result
+func (c *Action) Deploy(target *Target) error {
+	client, err := c.getClient()
+	if err != nil {
+		return err
+	}
+
+	owner, repo := target.Owner, target.Repo
+
+	// An empty list of contexts bypasses the commit status checks that are otherwise performed by GitHub
+	requiredContexts := []string(c.RequiredContexts)
+	if requiredContexts == nil {
+		requiredContexts = []string{}
+	}
+
+	req := &github.DeploymentRequest{
+		Ref:                   github.String(target.Ref),
+		Task:                  github.String(c.Task),
+		AutoMerge:             github.Bool(false),
+		RequiredContexts:      &requiredContexts,
+		Environment:           github.String(c.Environment),
+		TransientEnvironment:  github.Bool(c.Transient),
+		ProductionEnvironment: github.Bool(c.Production),
+	}
+
+	if c.Payload != "" {
+		req.Payload = github.String(c.Payload)
+	}
+
+	if c.Description != "" {
+		req.Description = github.String(c.Description)
+	}
+
+	deployment, _, err := client.Repositories.CreateDeployment(context.Background(), owner, repo, req)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Created deployment %d of %s to %q", deployment.GetID(), target.Ref, c.Environment)
+
+	if err := c.createStatus(client, target, deployment, "in_progress", c.Description); err != nil {
+		return err
+	}
+
+	log.Printf("Running command: %q", c.Cmd)
+
+	summary, _, runErr := actions.RunCmd(c.Cmd, c.Args)
+
+	state := "success"
+	if runErr != nil {
+		state = "failure"
+	}
+
+	if err := c.createStatus(client, target, deployment, state, lastLine(summary)); err != nil {
+		return err
+	}
+
+	if runErr == nil && c.InactivatePrevious {
+		if err := c.inactivatePrevious(client, target, deployment); err != nil {
+			return err
+		}
+	}
+
+	return runErr
+}
+
+func (c *Action) createStatus(client *github.Client, target *Target, deployment *github.Deployment, state, desc string) error {
+	// Deployment status descriptions are limited like commit status descriptions
+	desc = actions.Truncate(desc, actions.MaxDescriptionLength)
+
+	req := &github.DeploymentStatusRequest{
+		State:       github.String(state),
+		Description: github.String(desc),
+		Environment: github.String(c.Environment),
+		// Previous deployments are inactivated only when requested, in inactivatePrevious
+		AutoInactive: github.Bool(false),
+	}
+
+	if c.EnvironmentURL != "" {
+		req.EnvironmentURL = github.String(c.EnvironmentURL)
+	}
+
+	if c.LogURL != "" {
+		req.LogURL = github.String(c.LogURL)
+	}
+
+	_, _, err := client.Repositories.CreateDeploymentStatus(context.Background(), target.Owner, target.Repo, deployment.GetID(), req)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Set the status of deployment %d to %q", deployment.GetID(), state)
+
+	return nil
+}
+
+func (c *Action) inactivatePrevious(client *github.Client, target *Target, current *github.Deployment) error {
+	opt := &github.DeploymentsListOptions{
+		Environment: c.Environment,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var previous []*github.Deployment
+
+	for {
+		deployments, res, err := client.Repositories.ListDeployments(context.Background(), target.Owner, target.Repo, opt)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range deployments {
+			if d.GetID() != current.GetID() {
+				previous = append(previous, d)
+			}
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	for _, d := range previous {
+		statuses, _, err := client.Repositories.ListDeploymentStatuses(context.Background(), target.Owner, target.Repo, d.GetID(), &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return err
+		}
+
+		if len(statuses) > 0 && statuses[0].GetState() == "inactive" {
+			continue
+		}
+
+		_, _, err = client.Repositories.CreateDeploymentStatus(context.Background(), target.Owner, target.Repo, d.GetID(), &github.DeploymentStatusRequest{
+			State:       github.String("inactive"),
+			Description: github.String(fmt.Sprintf("Superseded by deployment %d", current.GetID())),
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Marked deployment %d as inactive", d.GetID())
+	}
+
+	return nil
+}
+
+func (c *Action) getClient() (*github.Client, error) {
+	return actions.CreateClient(os.Getenv("GITHUB_TOKEN"), c.BaseURL, c.UploadURL)
+}
+
+// lastLine returns the last non-empty line of the output, which usually tells the outcome rather than the progress
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
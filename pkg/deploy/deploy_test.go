@@ -0,0 +1,136 @@
+package deploy
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions/pkg/githubtest"
+)
+
+func TestDeploy(t *testing.T) {
+	os.Setenv("GITHUB_TOKEN", "token")
+
+	testcases := []struct {
+		cmd      *Action
+		script   string
+		err      string
+		expected map[int64][]string
+		// description is the description of the final status of the deployment
+		description string
+	}{
+		{
+			cmd:         &Action{Environment: "staging"},
+			script:      "echo deployed",
+			expected:    map[int64][]string{3: {"in_progress", "success"}},
+			description: "deployed",
+		},
+		{
+			// The last line tells the outcome, truncated without splitting multi-byte characters
+			cmd:         &Action{Environment: "staging"},
+			script:      "echo Deploying...; for i in $(seq 1 50); do printf 'デプロイ'; done; echo; echo",
+			expected:    map[int64][]string{3: {"in_progress", "success"}},
+			description: strings.Repeat("デプロイ", 34) + "デ...",
+		},
+		{
+			// Previous deployments not inactive yet are marked as inactive on success
+			cmd:         &Action{Environment: "staging", InactivatePrevious: true},
+			script:      "echo deployed",
+			expected:    map[int64][]string{3: {"in_progress", "success"}, 1: {"inactive"}},
+			description: "deployed",
+		},
+		{
+			// Previous deployments are kept on failure
+			cmd:         &Action{Environment: "staging", InactivatePrevious: true},
+			script:      "echo Deploying...; echo 'error: no space left' && exit 1",
+			err:         "exit status 1",
+			expected:    map[int64][]string{3: {"in_progress", "failure"}},
+			description: "error: no space left",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		s := githubtest.NewServer()
+
+		var mu sync.Mutex
+		var created *github.DeploymentRequest
+		states := map[int64][]string{}
+		var description string
+
+		s.Mux.HandleFunc("/repos/o/r/deployments", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				created = &github.DeploymentRequest{}
+				if err := json.NewDecoder(r.Body).Decode(created); err != nil {
+					t.Error(err)
+				}
+				githubtest.JSON(&github.Deployment{ID: github.Int64(3)})(w, r)
+				return
+			}
+
+			githubtest.JSON([]*github.Deployment{{ID: github.Int64(3)}, {ID: github.Int64(2)}, {ID: github.Int64(1)}})(w, r)
+		})
+
+		s.Mux.HandleFunc("/repos/o/r/deployments/", func(w http.ResponseWriter, r *http.Request) {
+			id, _ := strconv.ParseInt(strings.Split(strings.TrimPrefix(r.URL.Path, "/repos/o/r/deployments/"), "/")[0], 10, 64)
+
+			if r.Method == "POST" {
+				var req github.DeploymentStatusRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Error(err)
+				}
+				mu.Lock()
+				states[id] = append(states[id], req.GetState())
+				if id == 3 {
+					description = req.GetDescription()
+				}
+				mu.Unlock()
+				githubtest.JSON(&github.DeploymentStatus{State: req.State})(w, r)
+				return
+			}
+
+			// Deployment 2 has already been inactivated
+			latest := map[int64]string{1: "success", 2: "inactive"}
+			githubtest.JSON([]*github.DeploymentStatus{{State: github.String(latest[id])}})(w, r)
+		})
+
+		tc.cmd.BaseURL = s.BaseURL
+		tc.cmd.UploadURL = s.BaseURL
+		tc.cmd.Task = "deploy"
+		tc.cmd.Cmd = "sh"
+		tc.cmd.Args = []string{"-c", tc.script}
+
+		err := tc.cmd.Deploy(&Target{Owner: "o", Repo: "r", Ref: "abcdef"})
+
+		s.Close()
+
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, tc.err, err)
+			}
+		} else if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+
+		if created == nil {
+			t.Errorf("testcases[%d]: no deployment created", i)
+		} else if created.GetRef() != "abcdef" || created.GetEnvironment() != "staging" || created.RequiredContexts == nil || len(*created.RequiredContexts) != 0 {
+			t.Errorf("testcases[%d]: unexpected deployment request: %+v", i, created)
+		}
+
+		if description != tc.description {
+			t.Errorf("testcases[%d]: unexpected description: expected=%q, got=%q", i, tc.description, description)
+		}
+
+		if !reflect.DeepEqual(states, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected deployment statuses: expected=%v, got=%v", i, tc.expected, states)
+		}
+	}
+}
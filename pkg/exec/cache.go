@@ -0,0 +1,210 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+)
+
+// CacheKeyTree makes `exec` reuse the successful result of another commit that has the same tree as the pull request head
+const CacheKeyTree = "tree"
+
+type cachedResult struct {
+	sha, tree string
+
+	status   *github.RepoStatus
+	checkRun *github.CheckRun
+}
+
+// reuseCachedResult looks for a commit that has the same tree as the pull request head and a successful status and/or check run
+// for this action, and copies the result to the head when found.
+// It returns true when the result has been reused so that the command doesn't need to be run.
+func (c *Action) reuseCachedResult(pre *Target) (bool, error) {
+	if c.StatusContext == "" && c.checkRunName == "" {
+		return false, nil
+	}
+
+	client, err := c.instTokenClient()
+	if err != nil {
+		return false, err
+	}
+
+	cached, err := c.findCachedResult(client, pre)
+	if err != nil {
+		return false, err
+	}
+
+	if cached == nil {
+		log.Printf("No cached result found")
+		return false, nil
+	}
+
+	log.Printf("Reusing the result of commit %s that has the same tree %s", cached.sha, cached.tree)
+
+	summary := fmt.Sprintf("Reused the result of %s that has the same tree", shortSHA(cached.sha))
+
+	text := summary
+	if cached.checkRun != nil {
+		// Strip the code fence added by completeCheckRun, as PublishResult adds it again
+		text = strings.TrimSuffix(strings.TrimPrefix(cached.checkRun.GetOutput().GetText(), "```\n"), "\n```")
+	}
+
+	hit := *c
+	if hit.StatusTargetURL == "" && cached.status != nil {
+		hit.StatusTargetURL = cached.status.GetTargetURL()
+	}
+
+	if err := hit.PublishResult(pre, ConclusionSuccess, summary, text); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *Action) findCachedResult(client *github.Client, pre *Target) (*cachedResult, error) {
+	owner, repo := pre.Owner, pre.Repo
+	head := pre.PullRequest.Head.GetSHA()
+
+	headCommit, _, err := client.Git.GetCommit(context.Background(), owner, repo, head)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := headCommit.GetTree().GetSHA()
+
+	candidates, err := c.cacheCandidates(client, pre)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sha := range candidates {
+		commit, _, err := client.Git.GetCommit(context.Background(), owner, repo, sha)
+		if err != nil {
+			log.Printf("Skipping cache candidate %s: %v", sha, err)
+			continue
+		}
+
+		if commit.GetTree().GetSHA() != tree {
+			continue
+		}
+
+		cached := &cachedResult{sha: sha, tree: tree}
+
+		if c.StatusContext != "" {
+			st, err := latestStatus(client, owner, repo, sha, c.StatusContext)
+			if err != nil {
+				return nil, err
+			}
+
+			if st.GetState() != "success" {
+				continue
+			}
+
+			cached.status = st
+		}
+
+		if c.checkRunName != "" {
+			run, err := latestCheckRun(client, owner, repo, sha, c.checkRunName)
+			if err != nil {
+				return nil, err
+			}
+
+			if run.GetConclusion() != ConclusionSuccess {
+				continue
+			}
+
+			cached.checkRun = run
+		}
+
+		return cached, nil
+	}
+
+	return nil, nil
+}
+
+// cacheCandidates returns commits that may share the tree with the pull request head.
+// That is, the head before the force-push that triggered the event, and every commit in the pull request.
+func (c *Action) cacheCandidates(client *github.Client, pre *Target) ([]string, error) {
+	head := pre.PullRequest.Head.GetSHA()
+
+	seen := map[string]struct{}{head: {}}
+
+	var candidates []string
+
+	add := func(sha string) {
+		if _, ok := seen[sha]; ok || sha == "" {
+			return
+		}
+		seen[sha] = struct{}{}
+		candidates = append(candidates, sha)
+	}
+
+	add(actions.PullRequestBeforeSHA())
+
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		commits, res, err := client.PullRequests.ListCommits(context.Background(), pre.Owner, pre.Repo, pre.PullRequest.GetNumber(), opt)
+		if err != nil {
+			return nil, err
+		}
+
+		// Prefer newer commits
+		for i := len(commits) - 1; i >= 0; i-- {
+			add(commits[i].GetSHA())
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	return candidates, nil
+}
+
+// latestStatus returns the latest status for the context, or nil if there's none
+func latestStatus(client *github.Client, owner, repo, ref, statusContext string) (*github.RepoStatus, error) {
+	statuses, err := actions.ListLatestStatuses(client, owner, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range statuses {
+		if statuses[i].GetContext() == statusContext {
+			return &statuses[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// latestCheckRun returns the latest completed check run named `name`, or nil if there's none
+func latestCheckRun(client *github.Client, owner, repo, ref, name string) (*github.CheckRun, error) {
+	runs, _, err := client.Checks.ListCheckRunsForRef(context.Background(), owner, repo, ref, &github.ListCheckRunsOptions{
+		CheckName: github.String(name),
+		Status:    github.String("completed"),
+		Filter:    github.String("latest"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, run := range runs.CheckRuns {
+		if run.GetName() == name {
+			return run, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
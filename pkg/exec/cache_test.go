@@ -0,0 +1,168 @@
+package exec
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions/pkg/githubtest"
+)
+
+// fakeCommits serves the commits of pull request #1 and their trees, statuses and check runs.
+// The commits are "aaaaaaaa", "bbbbbbbb" and the head "cccccccc", where "aaaaaaaa" and the head have the same tree.
+func fakeCommits(s *githubtest.Server, statuses map[string][]github.RepoStatus, runs map[string][]*github.CheckRun) {
+	trees := map[string]string{
+		"aaaaaaaa": "tree1",
+		"bbbbbbbb": "tree2",
+		"cccccccc": "tree1",
+	}
+
+	s.Mux.HandleFunc("/repos/o/r/git/commits/", func(w http.ResponseWriter, r *http.Request) {
+		sha := strings.TrimPrefix(r.URL.Path, "/repos/o/r/git/commits/")
+		githubtest.JSON(&github.Commit{SHA: github.String(sha), Tree: &github.Tree{SHA: github.String(trees[sha])}})(w, r)
+	})
+
+	s.Mux.HandleFunc("/repos/o/r/pulls/1/commits", githubtest.JSON([]*github.RepositoryCommit{
+		{SHA: github.String("aaaaaaaa")},
+		{SHA: github.String("bbbbbbbb")},
+		{SHA: github.String("cccccccc")},
+	}))
+
+	s.Mux.HandleFunc("/repos/o/r/commits/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/repos/o/r/commits/"), "/")
+		switch parts[1] {
+		case "status":
+			githubtest.JSON(&github.CombinedStatus{Statuses: statuses[parts[0]]})(w, r)
+		case "check-runs":
+			githubtest.JSON(&github.ListCheckRunsResults{CheckRuns: runs[parts[0]]})(w, r)
+		default:
+			githubtest.NotFound(w, r)
+		}
+	})
+}
+
+func TestFindCachedResult(t *testing.T) {
+	os.Setenv("GITHUB_EVENT_NAME", "push")
+
+	status := func(context, state string) github.RepoStatus {
+		return github.RepoStatus{Context: github.String(context), State: github.String(state)}
+	}
+
+	run := func(name, conclusion string) *github.CheckRun {
+		return &github.CheckRun{Name: github.String(name), Status: github.String("completed"), Conclusion: github.String(conclusion)}
+	}
+
+	testcases := []struct {
+		cmd      *Action
+		statuses map[string][]github.RepoStatus
+		runs     map[string][]*github.CheckRun
+		expected string
+	}{
+		{
+			cmd:      &Action{StatusContext: "test"},
+			statuses: map[string][]github.RepoStatus{"aaaaaaaa": {status("lint", "success"), status("test", "success")}},
+			expected: "aaaaaaaa",
+		},
+		{
+			// A failed result is never reused
+			cmd:      &Action{StatusContext: "test"},
+			statuses: map[string][]github.RepoStatus{"aaaaaaaa": {status("test", "failure")}},
+		},
+		{
+			// The commit with a successful status has another tree
+			cmd:      &Action{StatusContext: "test"},
+			statuses: map[string][]github.RepoStatus{"bbbbbbbb": {status("test", "success")}},
+		},
+		{
+			cmd:      &Action{checkRunName: "test"},
+			runs:     map[string][]*github.CheckRun{"aaaaaaaa": {run("test", "success")}},
+			expected: "aaaaaaaa",
+		},
+		{
+			// Both the status and the check run must have succeeded
+			cmd:      &Action{StatusContext: "test", checkRunName: "test"},
+			statuses: map[string][]github.RepoStatus{"aaaaaaaa": {status("test", "success")}},
+			runs:     map[string][]*github.CheckRun{"aaaaaaaa": {run("test", "cancelled")}},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		s := githubtest.NewServer()
+
+		fakeCommits(s, tc.statuses, tc.runs)
+
+		pr := &github.PullRequest{Number: github.Int(1), Head: &github.PullRequestBranch{SHA: github.String("cccccccc")}}
+
+		cached, err := tc.cmd.findCachedResult(s.NewClient(t), &Target{Owner: "o", Repo: "r", PullRequest: pr})
+
+		s.Close()
+
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+			continue
+		}
+
+		var actual string
+		if cached != nil {
+			actual = cached.sha
+		}
+
+		if actual != tc.expected {
+			t.Errorf("testcases[%d]: unexpected cached commit: expected=%q, got=%q", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestReuseCachedResult(t *testing.T) {
+	os.Setenv("GITHUB_EVENT_NAME", "push")
+	os.Setenv("GITHUB_TOKEN", "token")
+
+	s := githubtest.NewServer()
+	defer s.Close()
+
+	fakeCommits(s, map[string][]github.RepoStatus{
+		"aaaaaaaa": {{Context: github.String("test"), State: github.String("success"), TargetURL: github.String("https://ci/1")}},
+	}, nil)
+
+	var created []github.RepoStatus
+	s.Mux.HandleFunc("/repos/o/r/statuses/cccccccc", func(w http.ResponseWriter, r *http.Request) {
+		var st github.RepoStatus
+		if err := json.NewDecoder(r.Body).Decode(&st); err != nil {
+			t.Error(err)
+		}
+		created = append(created, st)
+		githubtest.JSON(&st)(w, r)
+	})
+
+	cmd := &Action{BaseURL: s.BaseURL, UploadURL: s.BaseURL, StatusContext: "test", CacheKey: CacheKeyTree}
+
+	pr := &github.PullRequest{Number: github.Int(1), Head: &github.PullRequestBranch{SHA: github.String("cccccccc")}}
+
+	reused, err := cmd.reuseCachedResult(&Target{Owner: "o", Repo: "r", PullRequest: pr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reused {
+		t.Fatal("expected the cached result to be reused")
+	}
+
+	if len(created) != 1 {
+		t.Fatalf("unexpected number of statuses created: expected=1, got=%d", len(created))
+	}
+
+	st := created[0]
+
+	if st.GetState() != "success" || st.GetContext() != "test" || st.GetTargetURL() != "https://ci/1" {
+		t.Errorf("unexpected status: %+v", st)
+	}
+
+	if expected := "Reused the result of aaaaaaa that has the same tree"; st.GetDescription() != expected {
+		t.Errorf("unexpected description: expected=%q, got=%q", expected, st.GetDescription())
+	}
+}
@@ -0,0 +1,153 @@
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+)
+
+// maxCommentHistory is the maximum number of runs including the latest one to be kept in the comment
+const maxCommentHistory = 10
+
+var commentHistoryRegex = regexp.MustCompile(`<!-- go-actions/exec-history: (.*) -->`)
+
+type runRecord struct {
+	SHA        string    `json:"sha"`
+	Conclusion string    `json:"conclusion"`
+	Duration   string    `json:"duration"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+func (c *Action) commentKey() string {
+	if c.StatusContext != "" {
+		return c.StatusContext
+	}
+	if c.checkRunName != "" {
+		return c.checkRunName
+	}
+	return c.Cmd
+}
+
+// commentMarkerEscaper keeps the key from ending the HTML comment of the marker, as an HTML comment can't contain `--`
+var commentMarkerEscaper = strings.NewReplacer("--", "&#45;&#45;", ">", "&gt;")
+
+func commentMarker(key string) string {
+	return fmt.Sprintf("<!-- go-actions/exec: %s -->", commentMarkerEscaper.Replace(key))
+}
+
+// upsertComment posts the summary of the run to the pull request conversation,
+// or edits the comment posted by a previous run for the same status context or check run.
+func (c *Action) upsertComment(pre *Target, conclusion string, duration time.Duration, fullout string) error {
+	client, err := c.instTokenClient()
+	if err != nil {
+		return err
+	}
+
+	current := runRecord{
+		SHA:        pre.PullRequest.Head.GetSHA(),
+		Conclusion: conclusion,
+		Duration:   duration.Round(time.Second).String(),
+		FinishedAt: time.Now().UTC(),
+	}
+
+	return actions.UpsertComment(client, pre.Owner, pre.Repo, pre.PullRequest.GetNumber(), commentMarker(c.commentKey()), func(existing *github.IssueComment) string {
+		var history []runRecord
+		if existing != nil {
+			history = parseCommentHistory(existing.GetBody())
+		}
+
+		return renderComment(c.commentKey(), append([]runRecord{current}, history...), logTail(fullout, c.CommentLogLines))
+	})
+}
+
+func parseCommentHistory(body string) []runRecord {
+	m := commentHistoryRegex.FindStringSubmatch(body)
+	if m == nil {
+		return nil
+	}
+
+	var history []runRecord
+	if err := json.Unmarshal([]byte(m[1]), &history); err != nil {
+		log.Printf("Ignoring unparsable history in the comment: %v", err)
+		return nil
+	}
+
+	return history
+}
+
+// renderComment renders the comment body for the runs, the latest run being the first
+func renderComment(key string, runs []runRecord, logTail string) string {
+	if len(runs) > maxCommentHistory {
+		runs = runs[:maxCommentHistory]
+	}
+
+	latest := runs[0]
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n", commentMarker(key))
+	fmt.Fprintf(&b, "### `%s`: %s\n\n", key, latest.Conclusion)
+	fmt.Fprintf(&b, "**Commit**: %s\n", latest.SHA)
+	fmt.Fprintf(&b, "**Conclusion**: %s\n", latest.Conclusion)
+	fmt.Fprintf(&b, "**Duration**: %s\n\n", latest.Duration)
+
+	if logTail == "" {
+		fmt.Fprintf(&b, "<details>\n<summary>Log (no output)</summary>\n\n</details>\n")
+	} else {
+		fence := codeFence(logTail)
+		fmt.Fprintf(&b, "<details>\n<summary>Log (last %d lines)</summary>\n\n%s\n%s\n%s\n\n</details>\n", strings.Count(logTail, "\n")+1, fence, logTail, fence)
+	}
+
+	if len(runs) > 1 {
+		fmt.Fprintf(&b, "\n<details>\n<summary>Previous runs</summary>\n\n")
+		fmt.Fprintf(&b, "| Commit | Conclusion | Duration | Finished at |\n")
+		fmt.Fprintf(&b, "|--------|------------|----------|-------------|\n")
+		for _, r := range runs[1:] {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", r.SHA, r.Conclusion, r.Duration, r.FinishedAt.Format(time.RFC3339))
+		}
+		fmt.Fprintf(&b, "\n</details>\n")
+	}
+
+	history, err := json.Marshal(runs)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Fprintf(&b, "\n<!-- go-actions/exec-history: %s -->\n", string(history))
+
+	return b.String()
+}
+
+// codeFence returns a code fence longer than any run of backticks in the text, so that the text can't close the code block
+func codeFence(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+func logTail(out string, n int) string {
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,122 @@
+package exec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommentHistory(t *testing.T) {
+	finishedAt := time.Date(2019, 11, 1, 10, 0, 0, 0, time.UTC)
+
+	var runs []runRecord
+	for i := 0; i < maxCommentHistory+2; i++ {
+		r := runRecord{SHA: strings.Repeat(string(rune('a'+i)), 40), Conclusion: "success", Duration: "1m2s", FinishedAt: finishedAt}
+
+		body := renderComment("ci/test", append([]runRecord{r}, runs...), "line1\nline2")
+
+		if !strings.Contains(body, commentMarker("ci/test")) {
+			t.Fatalf("missing marker in comment:\n%s", body)
+		}
+
+		runs = parseCommentHistory(body)
+	}
+
+	if len(runs) != maxCommentHistory {
+		t.Fatalf("unexpected number of runs: expected=%d, got=%d", maxCommentHistory, len(runs))
+	}
+
+	if runs[0].SHA[0] != 'l' || runs[maxCommentHistory-1].SHA[0] != 'c' {
+		t.Errorf("unexpected runs: %v", runs)
+	}
+
+	if !reflect.DeepEqual(runs[0].FinishedAt, finishedAt) {
+		t.Errorf("unexpected finished_at: %v", runs[0].FinishedAt)
+	}
+}
+
+func TestCommentMarker(t *testing.T) {
+	testcases := []struct {
+		key      string
+		expected string
+	}{
+		{key: "ci/test", expected: "<!-- go-actions/exec: ci/test -->"},
+		{key: "go-test", expected: "<!-- go-actions/exec: go-test -->"},
+		// The key never ends the HTML comment early
+		{key: "x --> y", expected: "<!-- go-actions/exec: x &#45;&#45;&gt; y -->"},
+		{key: "x--->", expected: "<!-- go-actions/exec: x&#45;&#45;-&gt; -->"},
+	}
+
+	for i, tc := range testcases {
+		if actual := commentMarker(tc.key); actual != tc.expected {
+			t.Errorf("testcases[%d]: unexpected marker: expected=%q, got=%q", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestRenderComment(t *testing.T) {
+	finishedAt := time.Date(2019, 11, 1, 10, 0, 0, 0, time.UTC)
+
+	body := renderComment("ci/test", []runRecord{
+		{SHA: "sha2", Conclusion: "failure", Duration: "3s", FinishedAt: finishedAt},
+		{SHA: "sha1", Conclusion: "success", Duration: "2s", FinishedAt: finishedAt},
+	}, "FAIL")
+
+	for _, expected := range []string{
+		"### `ci/test`: failure\n",
+		"**Commit**: sha2\n",
+		"**Duration**: 3s\n",
+		"<summary>Log (last 1 lines)</summary>\n\n```\nFAIL\n```",
+		"| sha1 | success | 2s | 2019-11-01T10:00:00Z |\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("missing %q in comment:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRenderCommentLog(t *testing.T) {
+	run := runRecord{SHA: "sha1", Conclusion: "failure", Duration: "1s"}
+
+	testcases := []struct {
+		logTail  string
+		expected string
+	}{
+		{
+			logTail:  "",
+			expected: "<summary>Log (no output)</summary>\n\n</details>",
+		},
+		{
+			logTail:  "a\nb",
+			expected: "<summary>Log (last 2 lines)</summary>\n\n```\na\nb\n```\n",
+		},
+		{
+			// A fence in the log doesn't close the code block
+			logTail:  "```\ngo test\n```",
+			expected: "<summary>Log (last 3 lines)</summary>\n\n````\n```\ngo test\n```\n````\n",
+		},
+		{
+			logTail:  "x `````` y",
+			expected: "\n```````\nx `````` y\n```````\n",
+		},
+	}
+
+	for i, tc := range testcases {
+		body := renderComment("ci/test", []runRecord{run}, tc.logTail)
+
+		if !strings.Contains(body, tc.expected) {
+			t.Errorf("testcases[%d]: missing %q in comment:\n%s", i, tc.expected, body)
+		}
+	}
+}
+
+func TestLogTail(t *testing.T) {
+	if got := logTail("1\n2\n3\n", 2); got != "2\n3" {
+		t.Errorf("unexpected log tail: %q", got)
+	}
+
+	if got := logTail("1\n2\n3\n", 5); got != "1\n2\n3" {
+		t.Errorf("unexpected log tail: %q", got)
+	}
+}
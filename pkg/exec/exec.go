@@ -19,13 +19,33 @@ type Action struct {
 	BaseURL, UploadURL string
 
 	checkRunName string
+	// checkRunTitle is the title of the check run output. Defaults to Cmd when empty.
+	checkRunTitle string
 
 	StatusContext     string
 	StatusDescription string
 	StatusTargetURL   string
+	// SkippedStatusState is the commit status state a skipped step is reported with. Either "success" or "error"
+	SkippedStatusState string
+
+	// ConfigFile is the path to the pipeline config file. When set, `exec` runs the steps declared in it
+	// instead of the command given via the arguments.
+	ConfigFile string
+
+	// CacheKey is either empty or "tree". When "tree", the result of another commit that has the same tree is reused if any.
+	CacheKey string
+
+	// Comment makes `exec` post the summary of the run to the pull request, and edit it on later runs
+	Comment         bool
+	CommentLogLines int
 
 	Cmd  string
 	Args []string
+
+	// Dir is the working directory of the command. Defaults to the current directory when empty.
+	Dir string
+	// Env is the additional environment variables for the command, in the `KEY=VALUE` form.
+	Env []string
 }
 
 type Target struct {
@@ -47,6 +67,11 @@ func (c *Action) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&c.StatusContext, "status-context", "", "Commit status' context. If not empty, `exec` creates a status with this context")
 	fs.StringVar(&c.StatusDescription, "status-description", "", "Commit status' description. `exec` creates a status with this description")
 	fs.StringVar(&c.StatusTargetURL, "status-target-url", "", "Commit status' target_url. `exec` creates a status with this url as the link target")
+	fs.StringVar(&c.SkippedStatusState, "skipped-status-state", "success", `Commit status' state for a step of -config skipped by its condition. Either "success", or "error" to not let skipped steps pass required checks`)
+	fs.StringVar(&c.CacheKey, "cache-key", "", "Set to `tree` to skip running the command and reuse the successful status and/or check run of another commit that has the same tree as the pull request head")
+	fs.BoolVar(&c.Comment, "comment", false, "Post the summary of the run to the pull request conversation. Later runs for the same status context or check run edit the same comment")
+	fs.IntVar(&c.CommentLogLines, "comment-log-lines", 30, "Number of the last lines of the command output to be included in the comment")
+	fs.StringVar(&c.ConfigFile, "config", "", "Path to the pipeline config file like `.github/actions-exec.yaml`. If set, `exec` runs the steps declared in the file and reports each step as a check run and/or status")
 }
 
 func (c *Action) Run(args []string) error {
@@ -58,6 +83,16 @@ func (c *Action) Run(args []string) error {
 		c.Args = args[1:]
 	}
 
+	switch c.SkippedStatusState {
+	case "", "success", "error":
+	default:
+		return fmt.Errorf("unsupported skipped status state %q: expected either success or error", c.SkippedStatusState)
+	}
+
+	if c.CacheKey != "" && c.CacheKey != CacheKeyTree {
+		return fmt.Errorf("unsupported cache key %q: expected %q", c.CacheKey, CacheKeyTree)
+	}
+
 	pr, owner, repo, err := actions.PullRequest()
 	if err != nil {
 		return err
@@ -67,6 +102,14 @@ func (c *Action) Run(args []string) error {
 		Repo:        repo,
 		PullRequest: pr,
 	}
+
+	if c.ConfigFile != "" {
+		if c.Cmd != "" {
+			return fmt.Errorf("command %q can not be used with -config", c.Cmd)
+		}
+		return c.RunPipeline(target)
+	}
+
 	return c.EnsureCheckRun(target)
 }
 
@@ -134,6 +177,17 @@ func (c *Action) EnsureCheckRun(pre *Target) error {
 	repo := pre.Repo
 	sha := pre.PullRequest.Head.GetSHA()
 
+	if c.CacheKey == CacheKeyTree {
+		reused, err := c.reuseCachedResult(pre)
+		if err != nil {
+			return err
+		}
+
+		if reused {
+			return nil
+		}
+	}
+
 	if c.StatusContext != "" {
 		status := &github.RepoStatus{
 			State:       github.String("pending"),
@@ -152,8 +206,37 @@ func (c *Action) EnsureCheckRun(pre *Target) error {
 
 	log.Printf("Running command: %q", c.Cmd)
 
+	started := time.Now()
+
 	summary, text, runErr := c.runIt()
 
+	duration := time.Since(started)
+
+	if err := c.PublishResult(pre, conclusionOf(runErr), summary, text); err != nil {
+		return err
+	}
+
+	if c.Comment {
+		if err := c.upsertComment(pre, conclusionOf(runErr), duration, text); err != nil {
+			return err
+		}
+	}
+
+	return runErr
+}
+
+// PublishResult updates the check run and/or the commit status for the pull request head with the conclusion.
+// The conclusion is one of "success", "failure", "skipped" and "cancelled".
+func (c *Action) PublishResult(pre *Target, conclusion, summary, text string) error {
+	client, err := c.instTokenClient()
+	if err != nil {
+		return err
+	}
+
+	owner := pre.Owner
+	repo := pre.Repo
+	sha := pre.PullRequest.Head.GetSHA()
+
 	if c.checkRunName != "" {
 		suite, err := c.EnsureCheckSuite(pre)
 		if err != nil {
@@ -172,6 +255,9 @@ func (c *Action) EnsureCheckRun(pre *Target) error {
 			// TODO
 			//ListOptions: github.ListOptions{},
 		})
+		if err != nil {
+			return err
+		}
 
 		var checkRun *github.CheckRun
 		for _, existing := range checkRunsList.CheckRuns {
@@ -192,19 +278,12 @@ func (c *Action) EnsureCheckRun(pre *Target) error {
 		c.logCheckRun(checkRun)
 
 		log.Printf("Updating CheckRun")
-		if err := c.UpdateCheckRun(owner, repo, checkRun, summary, text, runErr); err != nil {
+		if err := c.completeCheckRun(owner, repo, checkRun, conclusion, summary, text); err != nil {
 			return err
 		}
 	}
 
 	if c.StatusContext != "" {
-		var state string
-		if runErr != nil {
-			state = "failure"
-		} else {
-			state = "success"
-		}
-
 		var desc string
 
 		if c.StatusDescription != "" {
@@ -214,7 +293,7 @@ func (c *Action) EnsureCheckRun(pre *Target) error {
 		}
 
 		status := &github.RepoStatus{
-			State:       github.String(state),
+			State:       github.String(c.statusStateOf(conclusion)),
 			Context:     github.String(c.StatusContext),
 			Description: github.String(desc),
 		}
@@ -228,7 +307,32 @@ func (c *Action) EnsureCheckRun(pre *Target) error {
 		}
 	}
 
-	return runErr
+	return nil
+}
+
+func conclusionOf(runErr error) string {
+	if runErr != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// statusStateOf maps a check run conclusion to a commit status state.
+// Commit statuses have no notion of "skipped", so it is reported as SkippedStatusState, with the reason in the description.
+// A step cancelled due to a failed need is reported as "error", so that it never passes required checks.
+func (c *Action) statusStateOf(conclusion string) string {
+	switch conclusion {
+	case ConclusionSuccess:
+		return "success"
+	case ConclusionSkipped:
+		if c.SkippedStatusState == "" {
+			return "success"
+		}
+		return c.SkippedStatusState
+	case ConclusionCancelled:
+		return "error"
+	}
+	return "failure"
 }
 
 func (c *Action) logCheckRun(checkRun *github.CheckRun) {
@@ -246,6 +350,10 @@ func (c *Action) EnsureCheckSuite(pre *Target) (*github.CheckSuite, error) {
 }
 
 func (c *Action) UpdateCheckRun(owner, repo string, checkRun *github.CheckRun, summary, text string, runErr error) error {
+	return c.completeCheckRun(owner, repo, checkRun, conclusionOf(runErr), summary, text)
+}
+
+func (c *Action) completeCheckRun(owner, repo string, checkRun *github.CheckRun, conclusion, summary, text string) error {
 	if checkRun.GetName() != c.checkRunName {
 		return fmt.Errorf("unexpected run name: expected %q, got %q", c.checkRunName, checkRun.GetName())
 	}
@@ -255,13 +363,6 @@ func (c *Action) UpdateCheckRun(owner, repo string, checkRun *github.CheckRun, s
 		return err
 	}
 
-	var conclusion string
-	if runErr != nil {
-		conclusion = "failure"
-	} else {
-		conclusion = "success"
-	}
-
 	// This panics due to missing field(in perhaps some cases)
 	//owner := checkRun.CheckSuite.Repository.Owner.GetLogin()
 	//repo := checkRun.CheckSuite.Repository.GetName()
@@ -276,7 +377,7 @@ func (c *Action) UpdateCheckRun(owner, repo string, checkRun *github.CheckRun, s
 		CompletedAt: &github.Timestamp{Time: time.Now()},
 		// See https://developer.github.com/v3/checks/runs/#output-object-1
 		Output: &github.CheckRunOutput{
-			Title:   github.String(c.Cmd),
+			Title:   github.String(c.title()),
 			Summary: github.String(fmt.Sprintf("```\n%s\n```", summary)),
 			Text:    github.String(fmt.Sprintf("```\n%s\n```", text)),
 		},
@@ -286,8 +387,15 @@ func (c *Action) UpdateCheckRun(owner, repo string, checkRun *github.CheckRun, s
 	return err
 }
 
+func (c *Action) title() string {
+	if c.checkRunTitle != "" {
+		return c.checkRunTitle
+	}
+	return c.Cmd
+}
+
 func (c *Action) runIt() (string, string, error) {
-	return actions.RunCmd(c.Cmd, c.Args)
+	return actions.RunCmdIn(c.Dir, c.Env, c.Cmd, c.Args)
 }
 
 func (c *Action) logResponseAndError(suites *github.ListCheckSuiteResults, res *github.Response, err error) error {
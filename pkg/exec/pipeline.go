@@ -0,0 +1,309 @@
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/variantdev/go-actions"
+	"gopkg.in/yaml.v2"
+)
+
+// Pipeline is the set of steps declared in the config file given via `exec -config`.
+//
+// A pipeline config looks like:
+//
+//	steps:
+//	- name: build
+//	  run: make build
+//	- name: test
+//	  needs: [build]
+//	  run: make test
+//	  working-directory: src
+//	  env:
+//	    GOFLAGS: -mod=vendor
+//	  if:
+//	    event: [pull_request]
+//	    labels: [ci/full]
+//	  status-context: ci/test
+type Pipeline struct {
+	Steps []Step `yaml:"steps"`
+}
+
+type Step struct {
+	Name string `yaml:"name"`
+	// Run is the shell snippet to be run with `sh -c`
+	Run   string     `yaml:"run"`
+	Needs []string   `yaml:"needs"`
+	If    *Condition `yaml:"if"`
+
+	Env              map[string]string `yaml:"env"`
+	WorkingDirectory string            `yaml:"working-directory"`
+
+	// CheckRunName defaults to the step name when neither of the check run name and the status context is set
+	CheckRunName      string `yaml:"check-run-name"`
+	StatusContext     string `yaml:"status-context"`
+	StatusDescription string `yaml:"status-description"`
+	StatusTargetURL   string `yaml:"status-target-url"`
+}
+
+// Condition is met when the triggering event is one of Events and the pull request has all the Labels
+type Condition struct {
+	Events []string `yaml:"event"`
+	Labels []string `yaml:"labels"`
+}
+
+const (
+	ConclusionSuccess = "success"
+	ConclusionFailure = "failure"
+	ConclusionSkipped = "skipped"
+	// ConclusionCancelled is the conclusion of a step not run because any of its needs failed or was cancelled.
+	// Unlike a step skipped by its condition, it never passes required checks
+	ConclusionCancelled = "cancelled"
+)
+
+func LoadPipeline(path string) (*Pipeline, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Pipeline
+
+	if err := yaml.UnmarshalStrict(bs, &p); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+
+	if _, err := p.order(); err != nil {
+		return nil, fmt.Errorf("validating %s: %v", path, err)
+	}
+
+	return &p, nil
+}
+
+// order returns the steps sorted topologically by their `needs`, preserving the declaration order among independent steps
+func (p *Pipeline) order() ([]Step, error) {
+	index := map[string]int{}
+
+	for i, s := range p.Steps {
+		if s.Name == "" {
+			return nil, fmt.Errorf("steps[%d]: missing name", i)
+		}
+		if s.Run == "" {
+			return nil, fmt.Errorf("step %q: missing run", s.Name)
+		}
+		if _, ok := index[s.Name]; ok {
+			return nil, fmt.Errorf("step %q: duplicate name", s.Name)
+		}
+		index[s.Name] = i
+	}
+
+	for _, s := range p.Steps {
+		for _, n := range s.Needs {
+			if _, ok := index[n]; !ok {
+				return nil, fmt.Errorf("step %q: needs unknown step %q", s.Name, n)
+			}
+		}
+	}
+
+	var ordered []Step
+
+	done := map[string]bool{}
+
+	for len(ordered) < len(p.Steps) {
+		progressed := false
+
+		for _, s := range p.Steps {
+			if done[s.Name] {
+				continue
+			}
+
+			ready := true
+			for _, n := range s.Needs {
+				ready = ready && done[n]
+			}
+
+			if ready {
+				ordered = append(ordered, s)
+				done[s.Name] = true
+				progressed = true
+			}
+		}
+
+		if !progressed {
+			var cyclic []string
+			for _, s := range p.Steps {
+				if !done[s.Name] {
+					cyclic = append(cyclic, s.Name)
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle among steps: %s", strings.Join(cyclic, ", "))
+		}
+	}
+
+	return ordered, nil
+}
+
+// Met returns true when the condition is met for the event and the pull request labels
+func (cond *Condition) Met(event string, labels []string) bool {
+	if cond == nil {
+		return true
+	}
+
+	if len(cond.Events) > 0 {
+		var found bool
+		for _, e := range cond.Events {
+			if e == event {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	labelSet := map[string]struct{}{}
+	for _, l := range labels {
+		labelSet[l] = struct{}{}
+	}
+
+	for _, l := range cond.Labels {
+		if _, ok := labelSet[l]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Execute runs the steps in the dependency order and returns the conclusion of each step keyed by the step name.
+//
+// `run` is called for each step to be run and should return a non-nil error when the step failed.
+// `skip` is called with the conclusion for each step that is not run. The conclusion is ConclusionSkipped when its condition is not met
+// or any of its needs was skipped, and ConclusionCancelled when any of its needs failed or was cancelled.
+func (p *Pipeline) Execute(event string, labels []string, run func(Step) error, skip func(Step, string, string) error) (map[string]string, error) {
+	steps, err := p.order()
+	if err != nil {
+		return nil, err
+	}
+
+	conclusions := map[string]string{}
+
+	for _, s := range steps {
+		var conclusion, reason string
+
+		for _, n := range s.Needs {
+			switch conclusions[n] {
+			case ConclusionSuccess:
+				continue
+			case ConclusionSkipped:
+				conclusion = ConclusionSkipped
+				reason = fmt.Sprintf("Skipped because the needed step %q was skipped", n)
+				continue
+			}
+			conclusion = ConclusionCancelled
+			reason = fmt.Sprintf("Cancelled because the needed step %q concluded %s", n, conclusions[n])
+			break
+		}
+
+		if conclusion == "" && !s.If.Met(event, labels) {
+			conclusion = ConclusionSkipped
+			reason = "Skipped because the condition was not met"
+		}
+
+		if conclusion != "" {
+			log.Printf("Not running step %q: %s", s.Name, reason)
+
+			conclusions[s.Name] = conclusion
+
+			if err := skip(s, conclusion, reason); err != nil {
+				return conclusions, err
+			}
+
+			continue
+		}
+
+		log.Printf("Running step %q", s.Name)
+
+		if err := run(s); err != nil {
+			log.Printf("Step %q failed: %v", s.Name, err)
+
+			conclusions[s.Name] = ConclusionFailure
+		} else {
+			conclusions[s.Name] = ConclusionSuccess
+		}
+	}
+
+	return conclusions, nil
+}
+
+// RunPipeline runs the steps declared in the config file and reports each step to GitHub
+func (c *Action) RunPipeline(pre *Target) error {
+	p, err := LoadPipeline(c.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	var labels []string
+	for _, l := range pre.PullRequest.Labels {
+		labels = append(labels, l.GetName())
+	}
+
+	conclusions, err := p.Execute(actions.EventName(), labels,
+		func(s Step) error {
+			return c.forStep(s).EnsureCheckRun(pre)
+		},
+		func(s Step, conclusion, reason string) error {
+			return c.forStep(s).PublishResult(pre, conclusion, reason, reason)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, s := range p.Steps {
+		if conclusions[s.Name] == ConclusionFailure {
+			failed = append(failed, s.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d step(s) failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func (c *Action) forStep(s Step) *Action {
+	var env []string
+	for k, v := range s.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+
+	checkRunName := s.CheckRunName
+	if checkRunName == "" && s.StatusContext == "" {
+		checkRunName = s.Name
+	}
+
+	return &Action{
+		BaseURL:            c.BaseURL,
+		UploadURL:          c.UploadURL,
+		CacheKey:           c.CacheKey,
+		Comment:            c.Comment,
+		CommentLogLines:    c.CommentLogLines,
+		checkRunName:       checkRunName,
+		checkRunTitle:      s.Name,
+		StatusContext:      s.StatusContext,
+		StatusDescription:  s.StatusDescription,
+		StatusTargetURL:    s.StatusTargetURL,
+		SkippedStatusState: c.SkippedStatusState,
+		Cmd:                "sh",
+		Args:               []string{"-c", s.Run},
+		Dir:                s.WorkingDirectory,
+		Env:                env,
+	}
+}
@@ -0,0 +1,176 @@
+package exec
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPipelineExecute(t *testing.T) {
+	p := &Pipeline{
+		Steps: []Step{
+			{Name: "test", Run: "make test", Needs: []string{"build"}},
+			{Name: "build", Run: "make build"},
+			{Name: "lint", Run: "make lint"},
+			{Name: "deploy", Run: "make deploy", Needs: []string{"test", "lint"}},
+			{Name: "e2e", Run: "make e2e", Needs: []string{"build"}, If: &Condition{Labels: []string{"ci/e2e"}}},
+			{Name: "report", Run: "make report", Needs: []string{"e2e"}},
+		},
+	}
+
+	var ran, skipped []string
+
+	conclusions, err := p.Execute("pull_request", []string{"size/s"},
+		func(s Step) error {
+			ran = append(ran, s.Name)
+			if s.Name == "lint" {
+				return fmt.Errorf("lint failed")
+			}
+			return nil
+		},
+		func(s Step, conclusion, reason string) error {
+			skipped = append(skipped, s.Name)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []string{"build", "lint", "test"}; !reflect.DeepEqual(expected, ran) {
+		t.Errorf("unexpected steps run: expected=%v, got=%v", expected, ran)
+	}
+
+	if expected := []string{"e2e", "report", "deploy"}; !reflect.DeepEqual(expected, skipped) {
+		t.Errorf("unexpected steps skipped: expected=%v, got=%v", expected, skipped)
+	}
+
+	expected := map[string]string{
+		"build":  ConclusionSuccess,
+		"test":   ConclusionSuccess,
+		"lint":   ConclusionFailure,
+		"deploy": ConclusionCancelled,
+		"e2e":    ConclusionSkipped,
+		"report": ConclusionSkipped,
+	}
+	if !reflect.DeepEqual(expected, conclusions) {
+		t.Errorf("unexpected conclusions: expected=%v, got=%v", expected, conclusions)
+	}
+}
+
+func TestPipelineOrderErrors(t *testing.T) {
+	testcases := []struct {
+		steps    []Step
+		expected string
+	}{
+		{
+			steps:    []Step{{Name: "a", Run: "true", Needs: []string{"b"}}, {Name: "b", Run: "true", Needs: []string{"a"}}},
+			expected: "dependency cycle among steps: a, b",
+		},
+		{
+			steps:    []Step{{Name: "a", Run: "true", Needs: []string{"c"}}},
+			expected: `step "a": needs unknown step "c"`,
+		},
+		{
+			steps:    []Step{{Name: "a", Run: "true"}, {Name: "a", Run: "true"}},
+			expected: `step "a": duplicate name`,
+		},
+		{
+			steps:    []Step{{Name: "a"}},
+			expected: `step "a": missing run`,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		p := &Pipeline{Steps: tc.steps}
+
+		_, err := p.order()
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("unexpected error: expected=%q, got=%v", tc.expected, err)
+		}
+	}
+}
+
+func TestConditionMet(t *testing.T) {
+	testcases := []struct {
+		cond     *Condition
+		event    string
+		labels   []string
+		expected bool
+	}{
+		{cond: nil, event: "pull_request", expected: true},
+		{cond: &Condition{Events: []string{"pull_request"}}, event: "pull_request", expected: true},
+		{cond: &Condition{Events: []string{"check_suite"}}, event: "pull_request", expected: false},
+		{cond: &Condition{Labels: []string{"a", "b"}}, event: "pull_request", labels: []string{"b", "a", "c"}, expected: true},
+		{cond: &Condition{Labels: []string{"a", "b"}}, event: "pull_request", labels: []string{"a"}, expected: false},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		if got := tc.cond.Met(tc.event, tc.labels); got != tc.expected {
+			t.Errorf("testcases[%d]: unexpected result: expected=%v, got=%v", i, tc.expected, got)
+		}
+	}
+}
+
+func TestPipelineExecuteFailedNeed(t *testing.T) {
+	p := &Pipeline{
+		Steps: []Step{
+			{Name: "build", Run: "make build"},
+			{Name: "test", Run: "make test", Needs: []string{"build"}},
+			{Name: "deploy", Run: "make deploy", Needs: []string{"test"}},
+		},
+	}
+
+	c := &Action{SkippedStatusState: "success"}
+
+	states := map[string]string{}
+
+	_, err := p.Execute("pull_request", nil,
+		func(s Step) error {
+			return fmt.Errorf("%s failed", s.Name)
+		},
+		func(s Step, conclusion, reason string) error {
+			states[s.Name] = c.statusStateOf(conclusion)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Dependents of the failed step must not pass required status checks
+	if expected := map[string]string{"test": "error", "deploy": "error"}; !reflect.DeepEqual(expected, states) {
+		t.Errorf("unexpected states: expected=%v, got=%v", expected, states)
+	}
+}
+
+func TestStatusStateOf(t *testing.T) {
+	testcases := []struct {
+		skippedState string
+		conclusion   string
+		expected     string
+	}{
+		{conclusion: ConclusionSuccess, expected: "success"},
+		{conclusion: ConclusionFailure, expected: "failure"},
+		{conclusion: ConclusionSkipped, expected: "success"},
+		{skippedState: "success", conclusion: ConclusionSkipped, expected: "success"},
+		{skippedState: "error", conclusion: ConclusionSkipped, expected: "error"},
+		{skippedState: "error", conclusion: ConclusionSuccess, expected: "success"},
+		// A step not run due to a failed need never passes, whatever the skipped state is
+		{conclusion: ConclusionCancelled, expected: "error"},
+		{skippedState: "success", conclusion: ConclusionCancelled, expected: "error"},
+	}
+
+	for i, tc := range testcases {
+		c := &Action{SkippedStatusState: tc.skippedState}
+
+		if actual := c.statusStateOf(tc.conclusion); actual != tc.expected {
+			t.Errorf("testcases[%d]: unexpected state: expected=%q, got=%q", i, tc.expected, actual)
+		}
+	}
+}
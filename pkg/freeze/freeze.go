@@ -0,0 +1,297 @@
+package freeze
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DefaultExceptionLabel is the label that allows merging pull requests during freeze windows
+	DefaultExceptionLabel = "freeze-exception"
+
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02T15:04"
+)
+
+// Config is the content of the freeze file, like:
+//
+//	timezone: America/Los_Angeles
+//	exception-label: freeze-exception
+//	windows:
+//	- name: holidays
+//	  start: "2019-12-20"
+//	  end: "2020-01-02"
+//	- name: release
+//	  start: "2019-11-01T18:00"
+//	  end: "2019-11-04T09:00"
+//	- name: weekends
+//	  cron: "* * * * 6,0"
+//	- name: friday evenings
+//	  cron: "* 17-23 * * 5"
+//	  timezone: Asia/Tokyo
+type Config struct {
+	// Timezone is the IANA time zone name the windows are declared in. Defaults to UTC
+	Timezone string `yaml:"timezone"`
+	// ExceptionLabel allows merging pull requests with the label during freeze windows. Defaults to DefaultExceptionLabel
+	ExceptionLabel string   `yaml:"exception-label"`
+	Windows        []Window `yaml:"windows"`
+}
+
+// Window is the period during which merges are frozen, declared either as a date range or a cron expression
+type Window struct {
+	Name string `yaml:"name"`
+	// Start and End are either dates like `2019-12-20` or times like `2019-12-20T18:00`.
+	// A date as End includes the whole day, whereas a time as End is exclusive
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+	// Cron is the cron expression in the `MINUTE HOUR DAY MONTH WEEKDAY` form. The window covers every minute the expression matches
+	Cron string `yaml:"cron"`
+	// Timezone overrides the time zone of the config
+	Timezone string `yaml:"timezone"`
+
+	loc        *time.Location
+	start, end time.Time
+	schedule   *schedule
+}
+
+// Parse parses and validates the freeze file
+func Parse(bs []byte) (*Config, error) {
+	var conf Config
+
+	if err := yaml.UnmarshalStrict(bs, &conf); err != nil {
+		return nil, err
+	}
+
+	if conf.ExceptionLabel == "" {
+		conf.ExceptionLabel = DefaultExceptionLabel
+	}
+
+	loc, err := time.LoadLocation(conf.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range conf.Windows {
+		w := &conf.Windows[i]
+
+		if w.Name == "" {
+			return nil, fmt.Errorf("windows[%d]: missing name", i)
+		}
+
+		if err := w.init(loc); err != nil {
+			return nil, fmt.Errorf("window %q: %v", w.Name, err)
+		}
+	}
+
+	return &conf, nil
+}
+
+func (w *Window) init(loc *time.Location) error {
+	w.loc = loc
+	if w.Timezone != "" {
+		var err error
+		w.loc, err = time.LoadLocation(w.Timezone)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case w.Cron != "" && (w.Start != "" || w.End != ""):
+		return fmt.Errorf("either cron or start and end can be set, not both")
+	case w.Cron != "":
+		s, err := parseSchedule(w.Cron)
+		if err != nil {
+			return fmt.Errorf("cron %q: %v", w.Cron, err)
+		}
+		w.schedule = s
+	case w.Start != "" && w.End != "":
+		start, _, err := parseTime(w.Start, w.loc)
+		if err != nil {
+			return fmt.Errorf("start: %v", err)
+		}
+		end, dateOnly, err := parseTime(w.End, w.loc)
+		if err != nil {
+			return fmt.Errorf("end: %v", err)
+		}
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !start.Before(end) {
+			return fmt.Errorf("end %q is not after start %q", w.End, w.Start)
+		}
+		w.start, w.end = start, end
+	default:
+		return fmt.Errorf("missing either cron or both start and end")
+	}
+
+	return nil
+}
+
+func parseTime(s string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(dateLayout, s, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.ParseInLocation(dateTimeLayout, s, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("unexpected format of %q: expected either YYYY-MM-DD or YYYY-MM-DDTHH:MM", s)
+	}
+	return t, false, nil
+}
+
+// Contains returns true when the time is in the window
+func (w *Window) Contains(t time.Time) bool {
+	t = t.In(w.loc)
+	if w.schedule != nil {
+		return w.schedule.matches(t)
+	}
+	return !t.Before(w.start) && t.Before(w.end)
+}
+
+// Active returns the first window containing the time, or nil if none does
+func (conf *Config) Active(t time.Time) *Window {
+	for i := range conf.Windows {
+		if conf.Windows[i].Contains(t) {
+			return &conf.Windows[i]
+		}
+	}
+	return nil
+}
+
+// Check returns the error describing the active freeze window, or nil if merges are allowed at the time.
+// Pull requests with the exception label are always allowed.
+func (conf *Config) Check(t time.Time, labels []string) error {
+	w := conf.Active(t)
+	if w == nil {
+		return nil
+	}
+
+	for _, l := range labels {
+		if l == conf.ExceptionLabel {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("merges are frozen during window %q. Add the label %q to merge anyway", w.Name, conf.ExceptionLabel)
+}
+
+// schedule is the parsed cron expression
+type schedule struct {
+	minute, hour, dom, month, dow fieldSet
+	// domAny and dowAny are true when the field starts with `*`, like `*` and `*/2`.
+	// Like cron, a time matches when either the day of month or the day of week matches if neither starts with `*`,
+	// and when both match otherwise
+	domAny, dowAny bool
+}
+
+type fieldSet map[int]struct{}
+
+func parseSchedule(expr string) (*schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	var s schedule
+	var err error
+
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %v", err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %v", err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %v", err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %v", err)
+	}
+	// 7 is also Sunday
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %v", err)
+	}
+	if _, ok := s.dow[7]; ok {
+		s.dow[0] = struct{}{}
+	}
+
+	s.domAny = strings.HasPrefix(fields[2], "*")
+	s.dowAny = strings.HasPrefix(fields[4], "*")
+
+	return &s, nil
+}
+
+// parseField parses a cron field like `*`, `5`, `1-5`, `*/15`, `9-17/2`, `5/10` and comma-separated lists of them.
+// Like cron, a single value followed by a step like `5/10` is the range from the value to the maximum.
+func parseField(field string, min, max int) (fieldSet, error) {
+	set := fieldSet{}
+
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+
+		slash := strings.Index(part, "/")
+		if slash >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[slash+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rng = part[:slash]
+		}
+
+		lo, hi := min, max
+
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+
+			var err error
+			lo, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			switch {
+			case len(bounds) == 2:
+				hi, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q", part)
+				}
+			case slash >= 0:
+				hi = max
+			default:
+				hi = lo
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q is out of the range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = struct{}{}
+		}
+	}
+
+	return set, nil
+}
+
+func (s fieldSet) has(v int) bool {
+	_, ok := s[v]
+	return ok
+}
+
+func (s *schedule) matches(t time.Time) bool {
+	if !s.minute.has(t.Minute()) || !s.hour.has(t.Hour()) || !s.month.has(int(t.Month())) {
+		return false
+	}
+
+	dom, dow := s.dom.has(t.Day()), s.dow.has(int(t.Weekday()))
+
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
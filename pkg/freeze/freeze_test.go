@@ -0,0 +1,180 @@
+package freeze
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	conf, err := Parse([]byte(`timezone: America/Los_Angeles
+windows:
+- name: holidays
+  start: "2019-12-20"
+  end: "2020-01-02"
+- name: release
+  start: "2019-11-01T18:00"
+  end: "2019-11-04T09:00"
+- name: weekends
+  cron: "* * * * 6,0"
+- name: friday evenings
+  cron: "* 17-23 * * 5"
+  timezone: Asia/Tokyo
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(s string) time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	testcases := []struct {
+		time     string
+		labels   []string
+		expected string
+	}{
+		// Tuesday
+		{time: "2019-10-15T12:00:00-07:00"},
+		{time: "2019-12-20T00:00:00-08:00", expected: "holidays"},
+		// The end date includes the whole day
+		{time: "2020-01-02T23:59:00-08:00", expected: "holidays"},
+		{time: "2020-01-03T09:00:00-08:00"},
+		{time: "2019-12-20T00:00:00-08:00", labels: []string{"freeze-exception"}},
+		// The end time is exclusive
+		{time: "2019-11-04T08:59:00-08:00", expected: "release"},
+		{time: "2019-11-04T09:00:00-08:00"},
+		// Saturday in Los Angeles, but Sunday in UTC
+		{time: "2019-10-19T23:00:00-07:00", expected: "weekends"},
+		// Friday 17:30 in Tokyo, but Friday 01:30 in Los Angeles
+		{time: "2019-10-18T17:30:00+09:00", expected: "friday evenings"},
+		{time: "2019-10-18T16:59:00+09:00"},
+	}
+
+	for i, tc := range testcases {
+		err := conf.Check(at(tc.time), tc.labels)
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+
+		if tc.expected != "" {
+			expected := `merges are frozen during window "` + tc.expected + `". Add the label "freeze-exception" to merge anyway`
+			if err == nil || err.Error() != expected {
+				t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, expected, err)
+			}
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "windows:\n- name: a\n  cron: \"* * *\"\n",
+			expected: `window "a": cron "* * *": expected 5 fields, got 3`,
+		},
+		{
+			input:    "windows:\n- name: a\n  cron: \"* 24 * * *\"\n",
+			expected: `window "a": cron "* 24 * * *": hour: "24" is out of the range 0-23`,
+		},
+		{
+			input:    "windows:\n- name: a\n  start: \"2019-12-20\"\n",
+			expected: `window "a": missing either cron or both start and end`,
+		},
+		{
+			input:    "windows:\n- name: a\n  start: \"2019-12-20\"\n  end: \"2019-12-19\"\n",
+			expected: `window "a": end "2019-12-19" is not after start "2019-12-20"`,
+		},
+		{
+			input:    "windows:\n- name: a\n  start: \"12/20\"\n  end: \"2019-12-19\"\n",
+			expected: `window "a": start: unexpected format of "12/20": expected either YYYY-MM-DD or YYYY-MM-DDTHH:MM`,
+		},
+	}
+
+	for i, tc := range testcases {
+		_, err := Parse([]byte(tc.input))
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, tc.expected, err)
+		}
+	}
+}
+
+func TestParseField(t *testing.T) {
+	testcases := []struct {
+		field    string
+		expected []int
+	}{
+		{field: "5", expected: []int{5}},
+		{field: "1-3,7", expected: []int{1, 2, 3, 7}},
+		{field: "*/15", expected: []int{0, 15, 30, 45}},
+		{field: "10-30/10", expected: []int{10, 20, 30}},
+		// A single value followed by a step ranges to the maximum, like cron
+		{field: "5/20", expected: []int{5, 25, 45}},
+	}
+
+	for i, tc := range testcases {
+		set, err := parseField(tc.field, 0, 59)
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+			continue
+		}
+
+		var actual []int
+		for v := 0; v <= 59; v++ {
+			if set.has(v) {
+				actual = append(actual, v)
+			}
+		}
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected values: expected=%v, got=%v", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestScheduleDays(t *testing.T) {
+	// 2019-10-01 is Tuesday
+	day := func(d int) time.Time {
+		return time.Date(2019, 10, d, 12, 0, 0, 0, time.UTC)
+	}
+
+	testcases := []struct {
+		cron     string
+		expected []int
+	}{
+		{cron: "* * * * *", expected: []int{1, 2, 3, 4, 5, 6, 7}},
+		{cron: "* * 3 * *", expected: []int{3}},
+		{cron: "* * * * 1", expected: []int{7}},
+		// Either the day of month or the day of week matches when both are restricted
+		{cron: "* * 3 * 1", expected: []int{3, 7}},
+		// Both must match when either starts with `*`, like cron
+		{cron: "* * */2 * 1", expected: []int{7}},
+		{cron: "* * 1-5 * */2", expected: []int{1, 3, 5}},
+	}
+
+	for i, tc := range testcases {
+		s, err := parseSchedule(tc.cron)
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+			continue
+		}
+
+		var actual []int
+		for d := 1; d <= 7; d++ {
+			if s.matches(day(d)) {
+				actual = append(actual, d)
+			}
+		}
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected days: expected=%v, got=%v", i, tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Package githubtest provides a fake GitHub API server for testing actions against
+package githubtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// apiPrefix is the path prefix that go-github adds to the base URL of GitHub Enterprise
+const apiPrefix = "/api/v3"
+
+// Server is a fake GitHub API server. Register handlers to Mux for paths like `/repos/OWNER/REPO/pulls/1`
+type Server struct {
+	Mux *http.ServeMux
+
+	// BaseURL is the value for the `-github-base-url` and `-github-upload-url` flags of actions
+	BaseURL string
+
+	server *httptest.Server
+}
+
+// NewServer starts a fake GitHub API server. Call Close to stop it
+func NewServer() *Server {
+	mux := http.NewServeMux()
+
+	api := http.NewServeMux()
+	api.Handle(apiPrefix+"/", http.StripPrefix(apiPrefix, mux))
+
+	server := httptest.NewServer(api)
+
+	return &Server{
+		Mux:     mux,
+		BaseURL: server.URL + apiPrefix + "/",
+		server:  server,
+	}
+}
+
+// NewClient returns a client for the server
+func (s *Server) NewClient(t *testing.T) *github.Client {
+	client, err := github.NewEnterpriseClient(s.BaseURL, s.BaseURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// JSON returns a handler that responds with v encoded in JSON
+func JSON(v interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// NotFound responds with 404 like GitHub does for missing resources
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"message":"Not Found"}`))
+}
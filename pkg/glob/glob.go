@@ -0,0 +1,67 @@
+// Package glob matches slash-separated paths against glob patterns.
+//
+// In addition to `*`, `?` and `[...]` that work like path.Match, `**` matches any number of directories.
+// A pattern ending with `/` matches everything under the directory.
+package glob
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Compile translates the glob pattern into an anchored regexp
+func Compile(pattern string) (*regexp.Regexp, error) {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	var b strings.Builder
+
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 3
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i += 2
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+			i++
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+			i++
+		case pattern[i] == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(pattern[i:]))
+				i = len(pattern)
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			i++
+		}
+	}
+
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// Match reports whether the path matches the glob pattern
+func Match(pattern, path string) (bool, error) {
+	r, err := Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return r.MatchString(path), nil
+}
@@ -0,0 +1,41 @@
+package glob
+
+import (
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	testcases := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{pattern: "deploy/**", path: "deploy/prod/values.yaml", expected: true},
+		{pattern: "deploy/**", path: "deploy.yaml", expected: false},
+		{pattern: "deploy/", path: "deploy/values.yaml", expected: true},
+		{pattern: "**/*.go", path: "main.go", expected: true},
+		{pattern: "**/*.go", path: "pkg/glob/glob.go", expected: true},
+		{pattern: "*.go", path: "pkg/glob/glob.go", expected: false},
+		{pattern: "pkg/**/*_test.go", path: "pkg/glob_test.go", expected: true},
+		{pattern: "pkg/**/*_test.go", path: "pkg/glob/glob_test.go", expected: true},
+		{pattern: "migrations/*.sql", path: "migrations/001.sql", expected: true},
+		{pattern: "migrations/*.sql", path: "migrations/old/001.sql", expected: false},
+		{pattern: "v?.md", path: "v1.md", expected: true},
+		{pattern: "[!a]*.md", path: "b.md", expected: true},
+		{pattern: "[!a]*.md", path: "a.md", expected: false},
+		{pattern: "vendor/**", path: "pkg/vendor/a.go", expected: false},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		got, err := Match(tc.pattern, tc.path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got != tc.expected {
+			t.Errorf("Match(%q, %q): expected=%v, got=%v", tc.pattern, tc.path, tc.expected, got)
+		}
+	}
+}
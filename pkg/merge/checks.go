@@ -0,0 +1,165 @@
+package merge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+)
+
+// Checks is the result of evaluating the required checks of the pull request
+type Checks struct {
+	// Required are the names of the required status contexts and check runs
+	Required []string
+	// Passing, Pending and Failing are the required checks by state. A required check that has never been reported is pending
+	Passing []string
+	Pending []string
+	Failing []string
+}
+
+// Passed returns true when every required check passed
+func (c *Checks) Passed() bool {
+	return len(c.Pending) == 0 && len(c.Failing) == 0
+}
+
+// Completed returns true when no required check is pending
+func (c *Checks) Completed() bool {
+	return len(c.Pending) == 0
+}
+
+// String describes the state of the required checks in a line
+func (c *Checks) String() string {
+	if len(c.Required) == 0 {
+		return "no checks are required"
+	}
+
+	var parts []string
+	if len(c.Failing) > 0 {
+		parts = append(parts, fmt.Sprintf("failing: %s", strings.Join(c.Failing, ", ")))
+	}
+	if len(c.Pending) > 0 {
+		parts = append(parts, fmt.Sprintf("pending: %s", strings.Join(c.Pending, ", ")))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%d required check(s) passed", len(c.Required))
+	}
+	return strings.Join(parts, "; ")
+}
+
+type checkState int
+
+// States in the order of precedence. When both a status and a check run have the name, the worse state wins
+const (
+	checkPassing checkState = iota
+	checkPending
+	checkFailing
+)
+
+func statusState(st github.RepoStatus) checkState {
+	switch st.GetState() {
+	case "success":
+		return checkPassing
+	case "pending":
+		return checkPending
+	default:
+		// "failure" and "error"
+		return checkFailing
+	}
+}
+
+func checkRunState(run *github.CheckRun) checkState {
+	if run.GetStatus() != "completed" {
+		return checkPending
+	}
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return checkPassing
+	default:
+		// "failure", "cancelled", "timed_out", "action_required" and "stale"
+		return checkFailing
+	}
+}
+
+// EvaluateChecks evaluates the required checks against the latest status per context and the latest check run per name
+func EvaluateChecks(required []string, statuses []github.RepoStatus, runs []*github.CheckRun) *Checks {
+	states := map[string]checkState{}
+
+	report := func(name string, s checkState) {
+		if prev, ok := states[name]; !ok || s > prev {
+			states[name] = s
+		}
+	}
+
+	for _, st := range statuses {
+		report(st.GetContext(), statusState(st))
+	}
+
+	for _, run := range runs {
+		report(run.GetName(), checkRunState(run))
+	}
+
+	checks := &Checks{Required: append([]string{}, required...)}
+	sort.Strings(checks.Required)
+
+	for _, name := range checks.Required {
+		s, ok := states[name]
+		switch {
+		case !ok || s == checkPending:
+			checks.Pending = append(checks.Pending, name)
+		case s == checkFailing:
+			checks.Failing = append(checks.Failing, name)
+		default:
+			checks.Passing = append(checks.Passing, name)
+		}
+	}
+
+	return checks
+}
+
+// requiredContexts returns the required status checks of the branch protection.
+// Nothing is required when the branch is not protected, or protected without required status checks.
+// GitHub responds with 404 also when the token can't read the branch protection, which is an error so that nothing is merged unchecked.
+func requiredContexts(client *github.Client, owner, repo, branch string) ([]string, error) {
+	checks, _, err := client.Repositories.GetRequiredStatusChecks(context.Background(), owner, repo, branch)
+	if err != nil {
+		if e, ok := err.(*github.ErrorResponse); ok && e.Response != nil && e.Response.StatusCode == 404 {
+			switch e.Message {
+			case "Branch not protected", "Required status checks not enabled":
+				log.Printf("Branch %q has no required status checks: %s", branch, e.Message)
+				return nil, nil
+			}
+			return nil, fmt.Errorf("%v: the token may lack the permission to read the branch protection", err)
+		}
+		return nil, err
+	}
+
+	if len(checks.Contexts) == 0 {
+		log.Printf("Branch %q has no required status checks", branch)
+	}
+
+	return checks.Contexts, nil
+}
+
+// GetChecks evaluates the required checks of the base branch against the commit
+func GetChecks(client *github.Client, owner, repo, base, sha string) (*Checks, error) {
+	required, err := requiredContexts(client, owner, repo, base)
+	if err != nil {
+		return nil, fmt.Errorf("getting required status checks of branch %q: %v", base, err)
+	}
+
+	statuses, err := actions.ListLatestStatuses(client, owner, repo, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := actions.ListLatestCheckRuns(client, owner, repo, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	return EvaluateChecks(required, statuses, runs), nil
+}
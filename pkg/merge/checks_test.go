@@ -0,0 +1,244 @@
+package merge
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions/pkg/githubtest"
+)
+
+func TestEvaluateChecks(t *testing.T) {
+	status := func(context, state string) github.RepoStatus {
+		return github.RepoStatus{Context: github.String(context), State: github.String(state)}
+	}
+
+	run := func(name, status, conclusion string) *github.CheckRun {
+		r := &github.CheckRun{Name: github.String(name), Status: github.String(status)}
+		if conclusion != "" {
+			r.Conclusion = github.String(conclusion)
+		}
+		return r
+	}
+
+	testcases := []struct {
+		required []string
+		statuses []github.RepoStatus
+		runs     []*github.CheckRun
+		expected Checks
+		passed   bool
+		message  string
+	}{
+		{
+			statuses: []github.RepoStatus{status("ci", "failure")},
+			expected: Checks{Required: []string{}},
+			passed:   true,
+			message:  "no checks are required",
+		},
+		{
+			required: []string{"lint", "ci"},
+			statuses: []github.RepoStatus{status("ci", "success"), status("other", "failure")},
+			runs:     []*github.CheckRun{run("lint", "completed", "neutral")},
+			expected: Checks{Required: []string{"ci", "lint"}, Passing: []string{"ci", "lint"}},
+			passed:   true,
+			message:  "2 required check(s) passed",
+		},
+		{
+			// A failing status doesn't count as passing
+			required: []string{"ci"},
+			statuses: []github.RepoStatus{status("ci", "error")},
+			expected: Checks{Required: []string{"ci"}, Failing: []string{"ci"}},
+			message:  "failing: ci",
+		},
+		{
+			// Required checks that have never been reported are pending
+			required: []string{"build", "ci", "test"},
+			statuses: []github.RepoStatus{status("ci", "pending")},
+			runs:     []*github.CheckRun{run("test", "in_progress", "")},
+			expected: Checks{Required: []string{"build", "ci", "test"}, Pending: []string{"build", "ci", "test"}},
+			message:  "pending: build, ci, test",
+		},
+		{
+			// The worse state wins when both a status and a check run have the name
+			required: []string{"build", "test"},
+			statuses: []github.RepoStatus{status("build", "success")},
+			runs:     []*github.CheckRun{run("build", "completed", "cancelled"), run("test", "completed", "success")},
+			expected: Checks{Required: []string{"build", "test"}, Passing: []string{"test"}, Failing: []string{"build"}},
+			message:  "failing: build",
+		},
+	}
+
+	for i, tc := range testcases {
+		actual := EvaluateChecks(tc.required, tc.statuses, tc.runs)
+
+		if !reflect.DeepEqual(*actual, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected checks: expected=%+v, got=%+v", i, tc.expected, *actual)
+		}
+
+		if actual.Passed() != tc.passed {
+			t.Errorf("testcases[%d]: unexpected result: expected=%v, got=%v", i, tc.passed, actual.Passed())
+		}
+
+		if actual.String() != tc.message {
+			t.Errorf("testcases[%d]: unexpected message: expected=%q, got=%q", i, tc.message, actual.String())
+		}
+	}
+}
+
+func TestWaitForChecks(t *testing.T) {
+	pending := &Checks{Required: []string{"ci", "lint"}, Passing: []string{"lint"}, Pending: []string{"ci"}}
+	passed := &Checks{Required: []string{"ci", "lint"}, Passing: []string{"ci", "lint"}}
+	failed := &Checks{Required: []string{"ci", "lint"}, Pending: []string{"lint"}, Failing: []string{"ci"}}
+
+	testcases := []struct {
+		polls    []*Checks
+		expected *Checks
+		err      string
+		sleeps   int
+	}{
+		{
+			polls:    []*Checks{pending, pending, passed},
+			expected: passed,
+			sleeps:   2,
+		},
+		{
+			// A failure ends waiting even though other checks are pending
+			polls:    []*Checks{pending, failed},
+			expected: failed,
+			sleeps:   1,
+		},
+		{
+			polls:  []*Checks{pending, pending, pending, pending, pending},
+			err:    "timed out after 1m30s waiting for required checks: pending: ci",
+			sleeps: 3,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		now := time.Date(2019, 10, 15, 12, 0, 0, 0, time.UTC)
+
+		var polls, sleeps int
+
+		cmd := &Action{
+			Timeout: 90 * time.Second,
+			Poll:    30 * time.Second,
+			Now:     func() time.Time { return now },
+			Sleep: func(d time.Duration) {
+				sleeps++
+				now = now.Add(d)
+			},
+		}
+
+		actual, err := cmd.waitForChecks(func() (*Checks, error) {
+			c := tc.polls[polls]
+			polls++
+			return c, nil
+		})
+
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, tc.err, err)
+			}
+		} else if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		} else if actual != tc.expected {
+			t.Errorf("testcases[%d]: unexpected checks: expected=%+v, got=%+v", i, tc.expected, actual)
+		}
+
+		if sleeps != tc.sleeps {
+			t.Errorf("testcases[%d]: unexpected number of sleeps: expected=%d, got=%d", i, tc.sleeps, sleeps)
+		}
+	}
+}
+
+func TestGetChecks(t *testing.T) {
+	testcases := []struct {
+		status     int
+		protection string
+		expected   Checks
+		log        string
+		err        string
+	}{
+		{
+			protection: `{"contexts":["ci"]}`,
+			expected:   Checks{Required: []string{"ci"}, Passing: []string{"ci"}},
+		},
+		{
+			protection: `{"contexts":[]}`,
+			expected:   Checks{Required: []string{}},
+			log:        `Branch "master" has no required status checks`,
+		},
+		{
+			status:     http.StatusNotFound,
+			protection: `{"message":"Branch not protected"}`,
+			expected:   Checks{Required: []string{}},
+			log:        `Branch "master" has no required status checks: Branch not protected`,
+		},
+		{
+			status:     http.StatusNotFound,
+			protection: `{"message":"Required status checks not enabled"}`,
+			expected:   Checks{Required: []string{}},
+			log:        `Branch "master" has no required status checks: Required status checks not enabled`,
+		},
+		{
+			// GitHub hides the branch protection from tokens that can't read it
+			status:     http.StatusNotFound,
+			protection: `{"message":"Not Found"}`,
+			err:        "the token may lack the permission to read the branch protection",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		s := githubtest.NewServer()
+
+		s.Mux.HandleFunc("/repos/o/r/branches/master/protection/required_status_checks", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if tc.status != 0 {
+				w.WriteHeader(tc.status)
+			}
+			w.Write([]byte(tc.protection))
+		})
+		s.Mux.HandleFunc("/repos/o/r/commits/abcdef/status", githubtest.JSON(&github.CombinedStatus{
+			Statuses: []github.RepoStatus{{Context: github.String("ci"), State: github.String("success")}},
+		}))
+		s.Mux.HandleFunc("/repos/o/r/commits/abcdef/check-runs", githubtest.JSON(&github.ListCheckRunsResults{}))
+
+		var logs bytes.Buffer
+		log.SetOutput(&logs)
+
+		checks, err := GetChecks(s.NewClient(t), "o", "r", "master", "abcdef")
+
+		log.SetOutput(os.Stderr)
+		s.Close()
+
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, tc.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(*checks, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected checks: expected=%+v, got=%+v", i, tc.expected, *checks)
+		}
+
+		if tc.log != "" && !strings.Contains(logs.String(), tc.log) {
+			t.Errorf("testcases[%d]: missing log %q in:\n%s", i, tc.log, logs.String())
+		}
+	}
+}
@@ -3,12 +3,14 @@ package merge
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
-	"strings"
+	"time"
 
 	"github.com/google/go-github/v28/github"
 	"github.com/variantdev/go-actions"
+	"github.com/variantdev/go-actions/pkg/freeze"
 )
 
 type Action struct {
@@ -16,6 +18,25 @@ type Action struct {
 
 	Force  bool
 	Method string
+
+	// MinOpen is the minimum duration the pull request must have been open for before merging
+	MinOpen time.Duration
+	// MinOpenSincePush measures MinOpen from the last push instead of the creation of the pull request
+	MinOpenSincePush bool
+	// FreezeFile is the path to the file in the base branch declaring freeze windows during which merges are refused
+	FreezeFile string
+
+	// Wait polls the required checks until they complete instead of returning immediately when they are pending
+	Wait bool
+	// Timeout is the maximum duration to wait for the required checks
+	Timeout time.Duration
+	// Poll is the interval between polls
+	Poll time.Duration
+
+	// Now returns the current time
+	Now func() time.Time
+	// Sleep pauses for the duration between polls
+	Sleep func(time.Duration)
 }
 
 type Target struct {
@@ -27,6 +48,8 @@ func New() *Action {
 	return &Action{
 		BaseURL:   "",
 		UploadURL: "",
+		Now:       time.Now,
+		Sleep:     time.Sleep,
 	}
 }
 
@@ -35,6 +58,12 @@ func (c *Action) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&c.UploadURL, "github-upload-url", "", "")
 	fs.BoolVar(&c.Force, "force", false, "Merges the pull request even if required checks are NOT passing")
 	fs.StringVar(&c.Method, "method", "merge", ` The merge method to use. Possible values include: "merge", "squash", and "rebase" with the default being merge`)
+	fs.BoolVar(&c.Wait, "wait", false, "If set, polls the required checks until they complete and merges the pull request when they passed. Fails when any of them failed, the timeout elapsed, or the pull request was updated while waiting")
+	fs.DurationVar(&c.Timeout, "timeout", 30*time.Minute, "Maximum duration to wait for the required checks with -wait")
+	fs.DurationVar(&c.Poll, "poll", 30*time.Second, "Interval between polls of the required checks with -wait")
+	fs.DurationVar(&c.MinOpen, "min-open", 0, "Refuses to merge the pull request until it has been open for the duration like 24h")
+	fs.BoolVar(&c.MinOpenSincePush, "min-open-since-push", false, "If set, -min-open is measured from the last push instead of the creation of the pull request")
+	fs.StringVar(&c.FreezeFile, "freeze-file", "", "Path to the file declaring freeze windows like `.github/freeze.yaml`. Merges are refused during the windows unless the pull request has the exception label. Read from the base branch")
 }
 
 func (c *Action) Run() error {
@@ -50,6 +79,31 @@ func (c *Action) Run() error {
 	return c.MergeIfNecessary(target)
 }
 
+// loadFreezeConfig reads the freeze file from the base branch like pullvet does,
+// so that a pull request can't lift the freeze windows blocking it by changing the file in its own head
+func (c *Action) loadFreezeConfig(client *github.Client, owner, repo, base string) (*freeze.Config, error) {
+	file, _, _, err := client.Repositories.GetContents(context.Background(), owner, repo, c.FreezeFile, &github.RepositoryContentGetOptions{Ref: base})
+	if err != nil {
+		return nil, fmt.Errorf("reading %s from branch %q: %v", c.FreezeFile, base, err)
+	}
+
+	if file == nil {
+		return nil, fmt.Errorf("%s is not a file", c.FreezeFile)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := freeze.Parse([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", c.FreezeFile, err)
+	}
+
+	return conf, nil
+}
+
 func (c *Action) MergeIfNecessary(pre *Target) error {
 	client, err := c.getClient()
 	if err != nil {
@@ -60,49 +114,130 @@ func (c *Action) MergeIfNecessary(pre *Target) error {
 	repo := pre.Repo
 	num := pre.PullRequest.GetNumber()
 
-	if !c.Force {
-		ref := pre.PullRequest.Head.GetRef()
-
-		headBranch := strings.TrimPrefix(ref, "refs/heads")
-
-		contexts, _, err := client.Repositories.ListRequiredStatusChecksContexts(context.Background(), owner, repo, headBranch)
+	since := pre.PullRequest.GetCreatedAt()
+	if c.MinOpen > 0 && c.MinOpenSincePush {
+		since, err = actions.PushedAt(client, owner, repo, pre.PullRequest)
 		if err != nil {
 			return err
 		}
+	}
 
-		// TODO Pagination
-		statuses, _, err := client.Repositories.ListStatuses(context.Background(), owner, repo, ref, &github.ListOptions{})
+	var frozen *freeze.Config
+	if c.FreezeFile != "" {
+		frozen, err = c.loadFreezeConfig(client, owner, repo, pre.PullRequest.GetBase().GetRef())
 		if err != nil {
 			return err
 		}
+	}
 
-		reqCheckContexts := map[string]struct{}{}
-		for _, c := range contexts {
-			reqCheckContexts[c] = struct{}{}
-			log.Printf("Seen required status context %q", c)
+	if reason := c.timingRefusal(pre.PullRequest, since, frozen); reason != "" {
+		log.Printf("Refused to merge the pull request: %s", reason)
+		return nil
+	}
+
+	sha := pre.PullRequest.GetHead().GetSHA()
+
+	if !c.Force {
+		get := func() (*Checks, error) {
+			return GetChecks(client, owner, repo, pre.PullRequest.GetBase().GetRef(), sha)
 		}
 
-		reqChecksPassing := true
-		for _, st := range statuses {
-			log.Printf("Seen status context %q", st.GetContext())
-			_, ok := reqCheckContexts[st.GetContext()]
-			reqChecksPassing = reqChecksPassing && ok
+		var checks *Checks
+		if c.Wait {
+			checks, err = c.waitForChecks(get)
+		} else {
+			checks, err = get()
+		}
+		if err != nil {
+			return err
 		}
 
-		if !reqChecksPassing {
+		if !checks.Passed() {
+			if c.Wait {
+				return fmt.Errorf("required checks failed: %s", checks)
+			}
+			log.Printf("Refused to merge the pull request: required checks are not passing: %s", checks)
 			return nil
 		}
+
+		log.Printf("Required checks are passing: %s", checks)
+
+		if c.Wait {
+			// Commits pushed while waiting haven't been checked
+			current, _, err := client.PullRequests.Get(context.Background(), owner, repo, num)
+			if err != nil {
+				return err
+			}
+			if head := current.GetHead().GetSHA(); head != sha {
+				return fmt.Errorf("the head of the pull request changed from %s to %s while waiting for required checks", sha, head)
+			}
+		}
 	}
 
 	log.Printf("Merging the pull request with method %q", c.Method)
 
 	_, _, mergeErr := client.PullRequests.Merge(context.Background(), owner, repo, num, "", &github.PullRequestOptions{
 		MergeMethod: c.Method,
+		SHA:         sha,
 	})
 
 	return mergeErr
 }
 
+// waitForChecks polls the required checks until none of them is pending, or any of them failed.
+// It fails when they are still pending after Timeout.
+func (c *Action) waitForChecks(get func() (*Checks, error)) (*Checks, error) {
+	deadline := c.Now().Add(c.Timeout)
+
+	for {
+		checks, err := get()
+		if err != nil {
+			return nil, err
+		}
+
+		if checks.Completed() || len(checks.Failing) > 0 {
+			return checks, nil
+		}
+
+		if !c.Now().Before(deadline) {
+			return nil, fmt.Errorf("timed out after %s waiting for required checks: %s", c.Timeout, checks)
+		}
+
+		log.Printf("Waiting %s for required checks: %s", c.Poll, checks)
+
+		c.Sleep(c.Poll)
+	}
+}
+
+// timingRefusal returns why the pull request can't be merged at the moment according to MinOpen and the freeze windows,
+// or an empty string if it can be merged
+func (c *Action) timingRefusal(pr *github.PullRequest, since time.Time, frozen *freeze.Config) string {
+	now := c.Now()
+
+	if c.MinOpen > 0 {
+		if open := now.Sub(since); open < c.MinOpen {
+			event := "opened"
+			if c.MinOpenSincePush {
+				event = "last pushed"
+			}
+			return fmt.Sprintf("%s %s ago, which is less than %s", event, open.Truncate(time.Minute), c.MinOpen)
+		}
+	}
+
+	if frozen != nil {
+		var labels []string
+		for _, l := range pr.Labels {
+			labels = append(labels, l.GetName())
+		}
+
+		if err := frozen.Check(now, labels); err != nil {
+			return err.Error()
+		}
+	}
+
+	return ""
+}
+
 func (c *Action) getClient() (*github.Client, error) {
 	return actions.CreateClient(os.Getenv("GITHUB_TOKEN"), c.BaseURL, c.UploadURL)
 }
@@ -0,0 +1,217 @@
+package merge
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions/pkg/freeze"
+	"github.com/variantdev/go-actions/pkg/githubtest"
+)
+
+func TestTimingRefusal(t *testing.T) {
+	// Saturday
+	now := time.Date(2019, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	weekends, err := freeze.Parse([]byte("windows:\n- name: weekends\n  cron: \"* * * * 6,0\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		cmd      *Action
+		since    time.Time
+		labels   []string
+		frozen   *freeze.Config
+		expected string
+	}{
+		{
+			cmd:   &Action{},
+			since: now,
+		},
+		{
+			cmd:   &Action{MinOpen: 24 * time.Hour},
+			since: now.Add(-24 * time.Hour),
+		},
+		{
+			cmd:      &Action{MinOpen: 24 * time.Hour},
+			since:    now.Add(-3 * time.Hour),
+			expected: "opened 3h0m0s ago, which is less than 24h0m0s",
+		},
+		{
+			cmd:      &Action{MinOpen: time.Hour, MinOpenSincePush: true},
+			since:    now.Add(-30 * time.Minute),
+			expected: "last pushed 30m0s ago, which is less than 1h0m0s",
+		},
+		{
+			cmd:      &Action{},
+			since:    now,
+			frozen:   weekends,
+			expected: `merges are frozen during window "weekends". Add the label "freeze-exception" to merge anyway`,
+		},
+		{
+			cmd:    &Action{},
+			since:  now,
+			labels: []string{"freeze-exception"},
+			frozen: weekends,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		tc.cmd.Now = func() time.Time { return now }
+
+		pr := &github.PullRequest{}
+		for _, l := range tc.labels {
+			pr.Labels = append(pr.Labels, &github.Label{Name: github.String(l)})
+		}
+
+		if actual := tc.cmd.timingRefusal(pr, tc.since, tc.frozen); actual != tc.expected {
+			t.Errorf("testcases[%d]: unexpected refusal: expected=%q, got=%q", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestMergeIfNecessaryMinOpenSincePush(t *testing.T) {
+	os.Setenv("GITHUB_TOKEN", "token")
+
+	now := time.Date(2019, 10, 15, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-72 * time.Hour)
+	// The committer date is set by the author, and can be backdated to bypass -min-open-since-push
+	backdated := now.Add(-48 * time.Hour)
+
+	testcases := []struct {
+		pushed time.Time
+		merged bool
+	}{
+		{
+			pushed: now.Add(-time.Hour),
+		},
+		{
+			pushed: now.Add(-25 * time.Hour),
+			merged: true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		s := githubtest.NewServer()
+
+		s.Mux.HandleFunc("/repos/o/r/pulls/1/commits", githubtest.JSON([]*github.RepositoryCommit{
+			{SHA: github.String("abcdef"), Commit: &github.Commit{Committer: &github.CommitAuthor{Date: &backdated}}},
+		}))
+		s.Mux.HandleFunc("/repos/o/r/issues/1/timeline", githubtest.JSON([]*github.Timeline{}))
+		s.Mux.HandleFunc("/repos/o/r/commits/abcdef/check-suites", githubtest.JSON(map[string]interface{}{
+			"total_count":  1,
+			"check_suites": []map[string]interface{}{{"id": 1, "created_at": tc.pushed}},
+		}))
+
+		var merged bool
+		s.Mux.HandleFunc("/repos/o/r/pulls/1/merge", func(w http.ResponseWriter, r *http.Request) {
+			merged = true
+			githubtest.JSON(&github.PullRequestMergeResult{Merged: github.Bool(true)})(w, r)
+		})
+
+		cmd := &Action{
+			BaseURL:          s.BaseURL,
+			UploadURL:        s.BaseURL,
+			Force:            true,
+			MinOpen:          24 * time.Hour,
+			MinOpenSincePush: true,
+			Now:              func() time.Time { return now },
+		}
+
+		pr := &github.PullRequest{
+			Number:    github.Int(1),
+			CreatedAt: &created,
+			Head:      &github.PullRequestBranch{SHA: github.String("abcdef")},
+		}
+
+		err := cmd.MergeIfNecessary(&Target{Owner: "o", Repo: "r", PullRequest: pr})
+
+		s.Close()
+
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+
+		if merged != tc.merged {
+			t.Errorf("testcases[%d]: unexpected merge: expected=%v, got=%v", i, tc.merged, merged)
+		}
+	}
+}
+
+func TestMergeIfNecessaryFreezeFile(t *testing.T) {
+	os.Setenv("GITHUB_TOKEN", "token")
+
+	// Saturday
+	now := time.Date(2019, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		base   string
+		merged bool
+	}{
+		{
+			base: "windows:\n- name: weekends\n  cron: \"* * * * 6,0\"\n",
+		},
+		{
+			base:   "windows: []\n",
+			merged: true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		s := githubtest.NewServer()
+
+		s.Mux.HandleFunc("/repos/o/r/contents/.github/freeze.yaml", func(w http.ResponseWriter, r *http.Request) {
+			// The file in the head of the pull request is never read
+			if ref := r.URL.Query().Get("ref"); ref != "master" {
+				t.Errorf("testcases[%d]: unexpected ref: %s", i, ref)
+			}
+			githubtest.JSON(&github.RepositoryContent{
+				Type:     github.String("file"),
+				Encoding: github.String("base64"),
+				Content:  github.String(base64.StdEncoding.EncodeToString([]byte(tc.base))),
+			})(w, r)
+		})
+
+		var merged bool
+		s.Mux.HandleFunc("/repos/o/r/pulls/1/merge", func(w http.ResponseWriter, r *http.Request) {
+			merged = true
+			githubtest.JSON(&github.PullRequestMergeResult{Merged: github.Bool(true)})(w, r)
+		})
+
+		cmd := &Action{
+			BaseURL:    s.BaseURL,
+			UploadURL:  s.BaseURL,
+			Force:      true,
+			FreezeFile: ".github/freeze.yaml",
+			Now:        func() time.Time { return now },
+		}
+
+		pr := &github.PullRequest{
+			Number: github.Int(1),
+			Base:   &github.PullRequestBranch{Ref: github.String("master")},
+			Head:   &github.PullRequestBranch{SHA: github.String("abcdef")},
+		}
+
+		err := cmd.MergeIfNecessary(&Target{Owner: "o", Repo: "r", PullRequest: pr})
+
+		s.Close()
+
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+
+		if merged != tc.merged {
+			t.Errorf("testcases[%d]: unexpected merge: expected=%v, got=%v", i, tc.merged, merged)
+		}
+	}
+}
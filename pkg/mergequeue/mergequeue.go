@@ -0,0 +1,355 @@
+package mergequeue
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+	"github.com/variantdev/go-actions/pkg/merge"
+	"github.com/variantdev/go-actions/pkg/rebase"
+)
+
+const (
+	DefaultLabel         = "queued"
+	DefaultTrackingLabel = "merge-queue"
+
+	// TrackingIssueTitle is the title of the tracking issue created when none exists
+	TrackingIssueTitle = "Merge queue"
+)
+
+type Action struct {
+	BaseURL, UploadURL string
+
+	// Label adds the pull request to the queue
+	Label string
+	// TrackingLabel identifies the issue whose body persists the order of the queue
+	TrackingLabel string
+	// Base limits the queue to pull requests against the branch
+	Base string
+	// Update is how the head of the queue is brought up to date with the base branch. Either "merge" or "rebase"
+	Update string
+	// Method is the merge method
+	Method string
+	// Timeout is the maximum duration to wait for the required checks of each pull request
+	Timeout time.Duration
+	// Poll is the interval between polls
+	Poll time.Duration
+
+	// Now returns the current time
+	Now func() time.Time
+	// Sleep pauses for the duration between polls
+	Sleep func(time.Duration)
+}
+
+func New() *Action {
+	return &Action{
+		BaseURL:   "",
+		UploadURL: "",
+		Now:       time.Now,
+		Sleep:     time.Sleep,
+	}
+}
+
+func (c *Action) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.BaseURL, "github-base-url", "", "")
+	fs.StringVar(&c.UploadURL, "github-upload-url", "", "")
+	fs.StringVar(&c.Label, "label", DefaultLabel, "Label that adds the pull request to the queue")
+	fs.StringVar(&c.TrackingLabel, "tracking-label", DefaultTrackingLabel, "Label of the issue that persists the order of the queue. The issue is created when missing")
+	fs.StringVar(&c.Base, "base", "", "Queue only pull requests against the base `branch`. By default, pull requests against any branch are queued")
+	fs.StringVar(&c.Update, "update", "merge", `How the head of the queue is updated with the base branch. Either "merge" or "rebase"`)
+	fs.StringVar(&c.Method, "method", "merge", `The merge method. Either "merge", "squash" or "rebase"`)
+	fs.DurationVar(&c.Timeout, "timeout", 30*time.Minute, "Maximum duration to wait for the required checks of each pull request")
+	fs.DurationVar(&c.Poll, "poll", 30*time.Second, "Interval between polls of the required checks")
+}
+
+func (c *Action) Run() error {
+	switch c.Update {
+	case "", "merge", "rebase":
+	default:
+		return fmt.Errorf("unsupported update %q: expected either merge or rebase", c.Update)
+	}
+
+	client, err := c.getClient()
+	if err != nil {
+		return err
+	}
+
+	owner, repo, err := actions.OwnerRepo()
+	if err != nil {
+		return err
+	}
+
+	return c.Process(client, owner, repo)
+}
+
+// Process merges the pull requests in the queue one by one, from the head.
+// The head of the queue is updated with the base branch, and merged once the required checks on the updated head pass.
+// A pull request that failed is ejected from the queue with a comment. Processing stops when a pull request was refused to be merged
+// for now, like during a freeze window, leaving it at the head of the queue.
+// Process doesn't lock the queue, so it must not run concurrently for the same repository.
+func (c *Action) Process(client *github.Client, owner, repo string) error {
+	issue, err := c.trackingIssue(client, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	queue, err := c.sync(client, owner, repo, ParseQueue(issue.GetBody()))
+	if err != nil {
+		return err
+	}
+
+	if err := c.save(client, owner, repo, issue, queue); err != nil {
+		return err
+	}
+
+	for len(queue) > 0 {
+		num := queue[0]
+
+		log.Printf("Processing pull request #%d at the head of the merge queue of %d", num, len(queue))
+
+		merged, err := c.process(client, owner, repo, num)
+		if err != nil {
+			log.Printf("Ejecting pull request #%d from the merge queue: %v", num, err)
+
+			if err := c.eject(client, owner, repo, num, err); err != nil {
+				return err
+			}
+		} else if !merged {
+			log.Printf("Stopped processing the merge queue: pull request #%d was not merged", num)
+			return nil
+		}
+
+		queue = remove(queue, num)
+
+		if err := c.save(client, owner, repo, issue, queue); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("The merge queue is empty")
+
+	return nil
+}
+
+// process updates the pull request with the base branch, waits for the required checks on the updated head and merges it.
+// It returns false when the pull request was refused to be merged for now.
+func (c *Action) process(client *github.Client, owner, repo string, num int) (bool, error) {
+	pr, _, err := client.PullRequests.Get(context.Background(), owner, repo, num)
+	if err != nil {
+		return false, err
+	}
+
+	if pr.GetMerged() {
+		return true, nil
+	}
+
+	if pr.GetState() != "open" {
+		return false, fmt.Errorf("the pull request is %s", pr.GetState())
+	}
+
+	pr, err = c.updateBranch(client, owner, repo, pr)
+	if err != nil {
+		return false, fmt.Errorf("updating the pull request with %s: %v", pr.GetBase().GetRef(), err)
+	}
+
+	m := merge.New()
+	m.BaseURL = c.BaseURL
+	m.UploadURL = c.UploadURL
+	m.Method = c.Method
+	m.Wait = true
+	m.Timeout = c.Timeout
+	m.Poll = c.Poll
+	m.Now = c.Now
+	m.Sleep = c.Sleep
+
+	if err := m.MergeIfNecessary(&merge.Target{Owner: owner, Repo: repo, PullRequest: pr}); err != nil {
+		return false, err
+	}
+
+	pr, _, err = client.PullRequests.Get(context.Background(), owner, repo, num)
+	if err != nil {
+		return false, err
+	}
+
+	return pr.GetMerged(), nil
+}
+
+// updateBranch brings the pull request up to date with the base branch, and returns the pull request with the updated head.
+// The pull request is returned as is when it is up to date.
+func (c *Action) updateBranch(client *github.Client, owner, repo string, pr *github.PullRequest) (*github.PullRequest, error) {
+	num := pr.GetNumber()
+	sha := pr.GetHead().GetSHA()
+
+	comparison, _, err := client.Repositories.CompareCommits(context.Background(), owner, repo, pr.GetBase().GetRef(), sha)
+	if err != nil {
+		return pr, err
+	}
+
+	if comparison.GetBehindBy() == 0 {
+		log.Printf("Pull request #%d is up to date with %s", num, pr.GetBase().GetRef())
+		return pr, nil
+	}
+
+	log.Printf("Updating pull request #%d that is %d commit(s) behind %s with %s", num, comparison.GetBehindBy(), pr.GetBase().GetRef(), c.update())
+
+	switch c.update() {
+	case "rebase":
+		r := rebase.New()
+		r.BaseURL = c.BaseURL
+		r.UploadURL = c.UploadURL
+
+		if err := r.ForcePushRebased(&rebase.Target{Owner: owner, Repo: repo, PullRequest: pr}); err != nil {
+			return pr, err
+		}
+	default:
+		_, _, err := client.PullRequests.UpdateBranch(context.Background(), owner, repo, num, &github.PullReqestBranchUpdateOptions{
+			ExpectedHeadSHA: github.String(sha),
+		})
+		// The branch is updated asynchronously
+		if _, ok := err.(*github.AcceptedError); !ok && err != nil {
+			return pr, err
+		}
+	}
+
+	return c.waitForHead(client, owner, repo, pr)
+}
+
+// waitForHead polls the pull request until its head moves from the previous head
+func (c *Action) waitForHead(client *github.Client, owner, repo string, prev *github.PullRequest) (*github.PullRequest, error) {
+	deadline := c.Now().Add(c.Timeout)
+
+	for {
+		pr, _, err := client.PullRequests.Get(context.Background(), owner, repo, prev.GetNumber())
+		if err != nil {
+			return prev, err
+		}
+
+		if pr.GetHead().GetSHA() != prev.GetHead().GetSHA() {
+			return pr, nil
+		}
+
+		if !c.Now().Before(deadline) {
+			return prev, fmt.Errorf("timed out after %s waiting for the head to be updated", c.Timeout)
+		}
+
+		c.Sleep(c.Poll)
+	}
+}
+
+// eject removes the pull request from the queue by removing the label, and explains why in a comment
+func (c *Action) eject(client *github.Client, owner, repo string, num int, reason error) error {
+	if err := actions.RemoveLabel(client, owner, repo, num, c.label()); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Removed from the merge queue: %v\n\nAdd the label `%s` again to re-queue the pull request.", reason, c.label())
+
+	_, _, err := client.Issues.CreateComment(context.Background(), owner, repo, num, &github.IssueComment{Body: github.String(body)})
+	return err
+}
+
+// sync updates the queue with the open pull requests currently labeled
+func (c *Action) sync(client *github.Client, owner, repo string, queue []int) ([]int, error) {
+	pulls, err := actions.ListOpenPullRequests(client, owner, repo, c.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	var labeled []int
+	for _, pr := range pulls {
+		for _, l := range pr.Labels {
+			if l.GetName() == c.label() {
+				labeled = append(labeled, pr.GetNumber())
+				break
+			}
+		}
+	}
+
+	return Sync(queue, labeled), nil
+}
+
+// trackingIssue returns the open issue labeled TrackingLabel, and creates one when missing
+func (c *Action) trackingIssue(client *github.Client, owner, repo string) (*github.Issue, error) {
+	opt := &github.IssueListByRepoOptions{
+		State:       "open",
+		Labels:      []string{c.trackingLabel()},
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		issues, res, err := client.Issues.ListByRepo(context.Background(), owner, repo, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		// Pull requests labeled TrackingLabel are listed too
+		for _, i := range issues {
+			if !i.IsPullRequest() {
+				return i, nil
+			}
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	log.Printf("Creating the tracking issue of the merge queue")
+
+	issue, _, err := client.Issues.Create(context.Background(), owner, repo, &github.IssueRequest{
+		Title:  github.String(TrackingIssueTitle),
+		Body:   github.String(FormatQueue(nil)),
+		Labels: &[]string{c.trackingLabel()},
+	})
+
+	return issue, err
+}
+
+// save persists the queue in the tracking issue, unless it is unchanged
+func (c *Action) save(client *github.Client, owner, repo string, issue *github.Issue, queue []int) error {
+	body := FormatQueue(queue)
+	if body == issue.GetBody() {
+		return nil
+	}
+
+	updated, _, err := client.Issues.Edit(context.Background(), owner, repo, issue.GetNumber(), &github.IssueRequest{
+		Body: github.String(body),
+	})
+	if err != nil {
+		return err
+	}
+
+	*issue = *updated
+
+	return nil
+}
+
+func (c *Action) label() string {
+	if c.Label == "" {
+		return DefaultLabel
+	}
+	return c.Label
+}
+
+func (c *Action) trackingLabel() string {
+	if c.TrackingLabel == "" {
+		return DefaultTrackingLabel
+	}
+	return c.TrackingLabel
+}
+
+func (c *Action) update() string {
+	if c.Update == "" {
+		return "merge"
+	}
+	return c.Update
+}
+
+func (c *Action) getClient() (*github.Client, error) {
+	return actions.CreateClient(os.Getenv("GITHUB_TOKEN"), c.BaseURL, c.UploadURL)
+}
@@ -0,0 +1,287 @@
+package mergequeue
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions/pkg/githubtest"
+)
+
+func TestParseQueue(t *testing.T) {
+	testcases := []struct {
+		body     string
+		expected []int
+	}{
+		{
+			body: FormatQueue(nil),
+		},
+		{
+			body:     FormatQueue([]int{12, 3, 7}),
+			expected: []int{12, 3, 7},
+		},
+		{
+			// Entries before the marker are not part of the queue, and duplicates are ignored
+			body:     "See 1. #99 for the background\r\n" + queueMarker + "\r\n1. #5\r\n2. #8 (needs rebase)\r\n3. #5\r\n- #6\r\n",
+			expected: []int{5, 8},
+		},
+	}
+
+	for i, tc := range testcases {
+		if actual := ParseQueue(tc.body); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected queue: expected=%v, got=%v", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	testcases := []struct {
+		queue    []int
+		labeled  []int
+		expected []int
+	}{
+		{
+			labeled:  []int{9, 2, 5},
+			expected: []int{2, 5, 9},
+		},
+		{
+			// The order of the pull requests already in the queue is kept
+			queue:    []int{9, 2},
+			labeled:  []int{1, 2, 9},
+			expected: []int{9, 2, 1},
+		},
+		{
+			// Pull requests no longer labeled are removed
+			queue:    []int{4, 3, 8},
+			labeled:  []int{8, 4, 10},
+			expected: []int{4, 8, 10},
+		},
+		{
+			queue: []int{4, 3},
+		},
+	}
+
+	for i, tc := range testcases {
+		if actual := Sync(tc.queue, tc.labeled); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected queue: expected=%v, got=%v", i, tc.expected, actual)
+		}
+	}
+}
+
+// fakeClock returns Now and Sleep funcs for Action that advance the time on every sleep, and the pointer to the number of sleeps
+func fakeClock() (func() time.Time, func(time.Duration), *int) {
+	now := time.Date(2019, 10, 15, 12, 0, 0, 0, time.UTC)
+	var sleeps int
+
+	return func() time.Time { return now }, func(d time.Duration) {
+		sleeps++
+		now = now.Add(d)
+	}, &sleeps
+}
+
+func TestUpdateBranch(t *testing.T) {
+	testcases := []struct {
+		behindBy int
+		status   int
+		heads    []string
+		expected string
+		err      string
+		updated  bool
+		sleeps   int
+	}{
+		{
+			behindBy: 0,
+			expected: "abcdef",
+		},
+		{
+			// The head moves once GitHub merged the base branch asynchronously
+			behindBy: 2,
+			status:   http.StatusAccepted,
+			heads:    []string{"abcdef", "abcdef", "123456"},
+			expected: "123456",
+			updated:  true,
+			sleeps:   2,
+		},
+		{
+			behindBy: 2,
+			status:   http.StatusUnprocessableEntity,
+			expected: "abcdef",
+			err:      "merge conflict",
+			updated:  true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		s := githubtest.NewServer()
+
+		var updated bool
+		var polls int
+
+		s.Mux.HandleFunc("/repos/o/r/compare/master...abcdef", githubtest.JSON(&github.CommitsComparison{BehindBy: github.Int(tc.behindBy)}))
+
+		s.Mux.HandleFunc("/repos/o/r/pulls/1/update-branch", func(w http.ResponseWriter, r *http.Request) {
+			var req github.PullReqestBranchUpdateOptions
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+			if req.GetExpectedHeadSHA() != "abcdef" {
+				t.Errorf("testcases[%d]: unexpected expected_head_sha: %s", i, req.GetExpectedHeadSHA())
+			}
+			updated = true
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(tc.status)
+			if tc.err != "" {
+				json.NewEncoder(w).Encode(map[string]string{"message": tc.err})
+				return
+			}
+			w.Write([]byte("{}"))
+		})
+
+		s.Mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+			head := tc.heads[polls]
+			polls++
+			githubtest.JSON(&github.PullRequest{Number: github.Int(1), Head: &github.PullRequestBranch{SHA: github.String(head)}})(w, r)
+		})
+
+		now, sleep, sleeps := fakeClock()
+
+		cmd := &Action{Timeout: 10 * time.Minute, Poll: 10 * time.Second, Now: now, Sleep: sleep}
+
+		pr := &github.PullRequest{
+			Number: github.Int(1),
+			Base:   &github.PullRequestBranch{Ref: github.String("master")},
+			Head:   &github.PullRequestBranch{SHA: github.String("abcdef")},
+		}
+
+		actual, err := cmd.updateBranch(s.NewClient(t), "o", "r", pr)
+
+		s.Close()
+
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, tc.err, err)
+			}
+		} else if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+
+		if actual.GetHead().GetSHA() != tc.expected {
+			t.Errorf("testcases[%d]: unexpected head: expected=%s, got=%s", i, tc.expected, actual.GetHead().GetSHA())
+		}
+
+		if updated != tc.updated {
+			t.Errorf("testcases[%d]: unexpected update: expected=%v, got=%v", i, tc.updated, updated)
+		}
+
+		if *sleeps != tc.sleeps {
+			t.Errorf("testcases[%d]: unexpected number of sleeps: expected=%d, got=%d", i, tc.sleeps, *sleeps)
+		}
+	}
+}
+
+func TestWaitForHeadTimeout(t *testing.T) {
+	s := githubtest.NewServer()
+	defer s.Close()
+
+	pr := &github.PullRequest{Number: github.Int(1), Head: &github.PullRequestBranch{SHA: github.String("abcdef")}}
+
+	s.Mux.HandleFunc("/repos/o/r/pulls/1", githubtest.JSON(pr))
+
+	now, sleep, sleeps := fakeClock()
+
+	cmd := &Action{Timeout: 90 * time.Second, Poll: 30 * time.Second, Now: now, Sleep: sleep}
+
+	actual, err := cmd.waitForHead(s.NewClient(t), "o", "r", pr)
+
+	if expected := "timed out after 1m30s waiting for the head to be updated"; err == nil || err.Error() != expected {
+		t.Errorf("unexpected error: expected=%q, got=%v", expected, err)
+	}
+
+	if actual != pr {
+		t.Errorf("unexpected pull request: %+v", actual)
+	}
+
+	if *sleeps != 3 {
+		t.Errorf("unexpected number of sleeps: expected=3, got=%d", *sleeps)
+	}
+}
+
+func TestEject(t *testing.T) {
+	s := githubtest.NewServer()
+	defer s.Close()
+
+	var removed, comments []string
+
+	s.Mux.HandleFunc("/repos/o/r/issues/1/labels/", func(w http.ResponseWriter, r *http.Request) {
+		// The label must be a single path segment
+		removed = append(removed, strings.TrimPrefix(r.URL.EscapedPath(), "/repos/o/r/issues/1/labels/"))
+		githubtest.JSON([]*github.Label{})(w, r)
+	})
+
+	s.Mux.HandleFunc("/repos/o/r/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		var cm github.IssueComment
+		if err := json.NewDecoder(r.Body).Decode(&cm); err != nil {
+			t.Error(err)
+		}
+		comments = append(comments, cm.GetBody())
+		githubtest.JSON(&cm)(w, r)
+	})
+
+	cmd := &Action{Label: "queue/ready"}
+	if err := cmd.eject(s.NewClient(t), "o", "r", 1, errors.New("required checks failed")); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"queue%2Fready"}; !reflect.DeepEqual(removed, expected) {
+		t.Errorf("unexpected removed labels: expected=%v, got=%v", expected, removed)
+	}
+
+	if expected := []string{"Removed from the merge queue: required checks failed\n\nAdd the label `queue/ready` again to re-queue the pull request."}; !reflect.DeepEqual(comments, expected) {
+		t.Errorf("unexpected comments: expected=%v, got=%v", expected, comments)
+	}
+}
+
+func TestTrackingIssue(t *testing.T) {
+	s := githubtest.NewServer()
+	defer s.Close()
+
+	var created bool
+
+	s.Mux.HandleFunc("/repos/o/r/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			created = true
+			githubtest.JSON(&github.Issue{Number: github.Int(3)})(w, r)
+			return
+		}
+
+		if labels := r.URL.Query().Get("labels"); labels != "merge-queue" {
+			t.Errorf("unexpected labels: %s", labels)
+		}
+
+		// The first page is full of pull requests labeled merge-queue
+		if r.URL.Query().Get("page") != "2" {
+			w.Header().Set("Link", `<`+s.BaseURL+`repos/o/r/issues?page=2>; rel="next"`)
+			githubtest.JSON([]*github.Issue{{Number: github.Int(1), PullRequestLinks: &github.PullRequestLinks{}}})(w, r)
+			return
+		}
+
+		githubtest.JSON([]*github.Issue{{Number: github.Int(2)}})(w, r)
+	})
+
+	issue, err := (&Action{}).trackingIssue(s.NewClient(t), "o", "r")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if issue.GetNumber() != 2 || created {
+		t.Errorf("unexpected tracking issue: number=%d, created=%v", issue.GetNumber(), created)
+	}
+}
@@ -0,0 +1,96 @@
+package mergequeue
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// queueMarker separates the description of the tracking issue from the queue
+const queueMarker = "<!-- mergequeue -->"
+
+// entryRegex matches the queue entries in the tracking issue, like `1. #123`
+var entryRegex = regexp.MustCompile(`(?m)^\s*\d+\.\s+#(\d+)\b`)
+
+// ParseQueue returns the numbers of the pull requests in the queue recorded in the body of the tracking issue, from the head
+func ParseQueue(body string) []int {
+	if i := strings.Index(body, queueMarker); i >= 0 {
+		body = body[i+len(queueMarker):]
+	}
+
+	var queue []int
+	seen := map[int]struct{}{}
+	for _, m := range entryRegex.FindAllStringSubmatch(body, -1) {
+		num, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[num]; ok {
+			continue
+		}
+		seen[num] = struct{}{}
+		queue = append(queue, num)
+	}
+	return queue
+}
+
+// FormatQueue renders the queue as the body of the tracking issue
+func FormatQueue(queue []int) string {
+	var b strings.Builder
+
+	b.WriteString("This issue tracks the merge queue. Add or remove the label on pull requests instead of editing it by hand.\n\n")
+	b.WriteString(queueMarker + "\n")
+
+	if len(queue) == 0 {
+		b.WriteString("The queue is empty.\n")
+	}
+
+	for i, num := range queue {
+		fmt.Fprintf(&b, "%d. #%d\n", i+1, num)
+	}
+
+	return b.String()
+}
+
+// Sync returns the queue updated with the pull requests currently labeled.
+// Pull requests no longer labeled are removed, and newly labeled ones are appended in the ascending order of numbers,
+// so that the order of the pull requests already in the queue never changes.
+func Sync(queue []int, labeled []int) []int {
+	labeledSet := map[int]struct{}{}
+	for _, num := range labeled {
+		labeledSet[num] = struct{}{}
+	}
+
+	var synced []int
+	inQueue := map[int]struct{}{}
+	for _, num := range queue {
+		if _, ok := labeledSet[num]; !ok {
+			continue
+		}
+		inQueue[num] = struct{}{}
+		synced = append(synced, num)
+	}
+
+	var added []int
+	for num := range labeledSet {
+		if _, ok := inQueue[num]; !ok {
+			added = append(added, num)
+		}
+	}
+	sort.Ints(added)
+
+	return append(synced, added...)
+}
+
+// remove returns the queue without the pull request
+func remove(queue []int, num int) []int {
+	var removed []int
+	for _, n := range queue {
+		if n != num {
+			removed = append(removed, n)
+		}
+	}
+	return removed
+}
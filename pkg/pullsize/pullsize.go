@@ -0,0 +1,267 @@
+package pullsize
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+	"github.com/variantdev/go-actions/pkg/glob"
+)
+
+const (
+	DefaultLabelPrefix = "size/"
+	DefaultThresholds  = "XS=0,S=10,M=30,L=100,XL=500"
+)
+
+type Action struct {
+	BaseURL, UploadURL string
+
+	// Excludes are globs of the paths excluded from the size, like generated files and vendored dependencies
+	Excludes actions.StringSlice
+	// Weights are given in the `GLOB=FACTOR` form, like `**/*_test.go=0.5`. The first matching weight applies
+	Weights actions.StringSlice
+	// Thresholds are given in the `NAME=MIN_LINES,...` form
+	Thresholds string
+	// LabelPrefix is prepended to the name of the size to make the label. No label is applied when empty
+	LabelPrefix string
+
+	// MaxLines and MaxFiles fail the pull request when it exceeds either of them. Zero means no limit
+	MaxLines int
+	MaxFiles int
+}
+
+// Size is the size of the pull request after excluding and weighting files
+type Size struct {
+	Files     int
+	Additions int
+	Deletions int
+	// Lines is the sum of additions and deletions, weighted by Weights
+	Lines int
+}
+
+// Threshold is the minimum number of lines for the size named Name
+type Threshold struct {
+	Name string
+	Min  int
+}
+
+type weight struct {
+	regexp *regexp.Regexp
+	factor float64
+}
+
+func New() *Action {
+	return &Action{}
+}
+
+func (c *Action) Run() error {
+	pr, owner, repo, err := actions.PullRequest()
+	if err != nil {
+		return err
+	}
+
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), c.BaseURL, c.UploadURL)
+	if err != nil {
+		return err
+	}
+
+	files, err := actions.ListPullRequestFiles(client, owner, repo, pr.GetNumber())
+	if err != nil {
+		return err
+	}
+
+	return c.HandlePullRequest(client, owner, repo, pr, files)
+}
+
+func (c *Action) HandlePullRequest(client *github.Client, owner, repo string, pr *github.PullRequest, files []*github.CommitFile) error {
+	thresholds, err := ParseThresholds(c.Thresholds)
+	if err != nil {
+		return err
+	}
+
+	size, err := c.Measure(files)
+	if err != nil {
+		return err
+	}
+
+	name := SizeName(thresholds, size.Lines)
+
+	fmt.Fprintf(os.Stdout, "%d file(s) changed, +%d -%d, %d weighted line(s): %s\n", size.Files, size.Additions, size.Deletions, size.Lines, name)
+
+	if c.LabelPrefix != "" {
+		if err := c.updateLabels(client, owner, repo, pr, thresholds, c.LabelPrefix+name); err != nil {
+			return err
+		}
+	}
+
+	var failures []string
+
+	if c.MaxLines > 0 && size.Lines > c.MaxLines {
+		failures = append(failures, fmt.Sprintf("%d weighted line(s) changed, expected at most %d", size.Lines, c.MaxLines))
+	}
+
+	if c.MaxFiles > 0 && size.Files > c.MaxFiles {
+		failures = append(failures, fmt.Sprintf("%d file(s) changed, expected at most %d", size.Files, c.MaxFiles))
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("pull request is too large: %s", strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+// Measure computes the size of the files, excluding and weighting them according to Excludes and Weights
+func (c *Action) Measure(files []*github.CommitFile) (*Size, error) {
+	var excludes []*regexp.Regexp
+	for _, e := range c.Excludes {
+		r, err := glob.Compile(e)
+		if err != nil {
+			return nil, err
+		}
+		excludes = append(excludes, r)
+	}
+
+	var weights []weight
+	for _, w := range c.Weights {
+		kv := strings.SplitN(w, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("unexpected format of weight %q: expected GLOB=FACTOR", w)
+		}
+
+		r, err := glob.Compile(kv[0])
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil || f < 0 {
+			return nil, fmt.Errorf("unexpected format of weight %q: FACTOR must be a non-negative number", w)
+		}
+
+		weights = append(weights, weight{regexp: r, factor: f})
+	}
+
+	size := &Size{}
+
+	var lines float64
+
+FILES:
+	for _, f := range files {
+		path := f.GetFilename()
+
+		for _, r := range excludes {
+			if r.MatchString(path) {
+				log.Printf("Excluded %s", path)
+				continue FILES
+			}
+		}
+
+		factor := 1.0
+		for _, w := range weights {
+			if w.regexp.MatchString(path) {
+				factor = w.factor
+				break
+			}
+		}
+
+		size.Files++
+		size.Additions += f.GetAdditions()
+		size.Deletions += f.GetDeletions()
+
+		lines += factor * float64(f.GetAdditions()+f.GetDeletions())
+	}
+
+	size.Lines = int(math.Round(lines))
+
+	return size, nil
+}
+
+// ParseThresholds parses thresholds given in the `NAME=MIN_LINES,...` form, like `XS=0,S=10,M=30,L=100,XL=500`
+func ParseThresholds(s string) ([]Threshold, error) {
+	var thresholds []Threshold
+
+	for _, item := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("unexpected format of threshold %q: expected NAME=MIN_LINES", item)
+		}
+
+		min, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected format of threshold %q: %v", item, err)
+		}
+
+		if n := len(thresholds); n > 0 && thresholds[n-1].Min >= min {
+			return nil, fmt.Errorf("threshold %q must be greater than the previous one", item)
+		}
+
+		thresholds = append(thresholds, Threshold{Name: kv[0], Min: min})
+	}
+
+	return thresholds, nil
+}
+
+// SizeName returns the name of the largest threshold the lines reach, or the smallest one if none
+func SizeName(thresholds []Threshold, lines int) string {
+	name := thresholds[0].Name
+	for _, t := range thresholds {
+		if lines >= t.Min {
+			name = t.Name
+		}
+	}
+	return name
+}
+
+// StaleLabels returns the size labels on the pull request other than the desired one
+func StaleLabels(labels []string, prefix string, thresholds []Threshold, desired string) []string {
+	sizeLabels := map[string]struct{}{}
+	for _, t := range thresholds {
+		sizeLabels[prefix+t.Name] = struct{}{}
+	}
+
+	var stale []string
+	for _, l := range labels {
+		if _, ok := sizeLabels[l]; ok && l != desired {
+			stale = append(stale, l)
+		}
+	}
+	return stale
+}
+
+// updateLabels applies exactly one size label, removing the stale ones
+func (c *Action) updateLabels(client *github.Client, owner, repo string, pr *github.PullRequest, thresholds []Threshold, desired string) error {
+	var labels []string
+	var found bool
+	for _, l := range pr.Labels {
+		labels = append(labels, l.GetName())
+		if l.GetName() == desired {
+			found = true
+		}
+	}
+
+	for _, l := range StaleLabels(labels, c.LabelPrefix, thresholds, desired) {
+		log.Printf("Removing stale label %q", l)
+
+		if err := actions.RemoveLabel(client, owner, repo, pr.GetNumber(), l); err != nil {
+			return err
+		}
+	}
+
+	if found {
+		return nil
+	}
+
+	log.Printf("Adding label %q", desired)
+
+	_, _, err := client.Issues.AddLabelsToIssue(context.Background(), owner, repo, pr.GetNumber(), []string{desired})
+
+	return err
+}
@@ -0,0 +1,165 @@
+package pullsize
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions/pkg/githubtest"
+)
+
+func TestMeasure(t *testing.T) {
+	file := func(path string, additions, deletions int) *github.CommitFile {
+		return &github.CommitFile{Filename: github.String(path), Additions: github.Int(additions), Deletions: github.Int(deletions)}
+	}
+
+	files := []*github.CommitFile{
+		file("main.go", 20, 5),
+		file("main_test.go", 30, 10),
+		file("vendor/github.com/foo/bar.go", 1000, 0),
+		file("pkg/api/zz_generated.deepcopy.go", 300, 100),
+	}
+
+	testcases := []struct {
+		cmd      *Action
+		expected Size
+	}{
+		{
+			cmd:      &Action{},
+			expected: Size{Files: 4, Additions: 1350, Deletions: 115, Lines: 1465},
+		},
+		{
+			cmd:      &Action{Excludes: []string{"vendor/", "**/zz_generated.*.go"}},
+			expected: Size{Files: 2, Additions: 50, Deletions: 15, Lines: 65},
+		},
+		{
+			cmd:      &Action{Excludes: []string{"vendor/", "**/zz_generated.*.go"}, Weights: []string{"**/*_test.go=0.5", "**/*.go=2"}},
+			expected: Size{Files: 2, Additions: 50, Deletions: 15, Lines: 70},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		size, err := tc.cmd.Measure(files)
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+			continue
+		}
+
+		if *size != tc.expected {
+			t.Errorf("testcases[%d]: unexpected size: expected=%+v, got=%+v", i, tc.expected, *size)
+		}
+	}
+}
+
+func TestSizeName(t *testing.T) {
+	thresholds, err := ParseThresholds(DefaultThresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		input    int
+		expected string
+	}{
+		{input: 0, expected: "XS"},
+		{input: 9, expected: "XS"},
+		{input: 10, expected: "S"},
+		{input: 99, expected: "M"},
+		{input: 100, expected: "L"},
+		{input: 10000, expected: "XL"},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		if got := SizeName(thresholds, tc.input); got != tc.expected {
+			t.Errorf("testcases[%d]: unexpected size: expected=%q, got=%q", i, tc.expected, got)
+		}
+	}
+}
+
+func TestParseThresholdsErrors(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "S=10,M", expected: `unexpected format of threshold "M": expected NAME=MIN_LINES`},
+		{input: "S=10,M=x", expected: `unexpected format of threshold "M=x"`},
+		{input: "S=10,M=10", expected: `threshold "M=10" must be greater than the previous one`},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		_, err := ParseThresholds(tc.input)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, tc.expected, err)
+		}
+	}
+}
+
+func TestStaleLabels(t *testing.T) {
+	thresholds, err := ParseThresholds(DefaultThresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := StaleLabels([]string{"size/XS", "size/M", "size/XXL", "bug"}, DefaultLabelPrefix, thresholds, "size/M")
+	expected := []string{"size/XS"}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected stale labels: expected=%v, got=%v", expected, got)
+	}
+}
+
+func TestUpdateLabels(t *testing.T) {
+	thresholds, err := ParseThresholds(DefaultThresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := githubtest.NewServer()
+	defer s.Close()
+
+	var removed, added []string
+
+	s.Mux.HandleFunc("/repos/o/r/issues/1/labels/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Errorf("unexpected method: %s", r.Method)
+		}
+		// The label must be a single path segment
+		removed = append(removed, strings.TrimPrefix(r.URL.EscapedPath(), "/repos/o/r/issues/1/labels/"))
+		githubtest.JSON([]*github.Label{})(w, r)
+	})
+
+	s.Mux.HandleFunc("/repos/o/r/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&added); err != nil {
+			t.Error(err)
+		}
+		githubtest.JSON([]*github.Label{})(w, r)
+	})
+
+	pr := &github.PullRequest{
+		Number: github.Int(1),
+		Labels: []*github.Label{{Name: github.String("size/XS")}, {Name: github.String("bug")}},
+	}
+
+	c := &Action{LabelPrefix: DefaultLabelPrefix}
+
+	if err := c.updateLabels(s.NewClient(t), "o", "r", pr, thresholds, "size/M"); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"size%2FXS"}; !reflect.DeepEqual(removed, expected) {
+		t.Errorf("unexpected labels removed: expected=%v, got=%v", expected, removed)
+	}
+
+	if expected := []string{"size/M"}; !reflect.DeepEqual(added, expected) {
+		t.Errorf("unexpected labels added: expected=%v, got=%v", expected, added)
+	}
+}
@@ -0,0 +1,140 @@
+package pullvet
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestApprovals(t *testing.T) {
+	stubPRBody := func(owner, repo string, num int) (string, error) {
+		return "", nil
+	}
+
+	at := func(min int) *time.Time {
+		t := time.Date(2019, 11, 1, 0, min, 0, 0, time.UTC)
+		return &t
+	}
+
+	review := func(login, state, commit string, min int) *github.PullRequestReview {
+		return &github.PullRequestReview{
+			User:        &github.User{Login: github.String(login)},
+			State:       github.String(state),
+			CommitID:    github.String(commit),
+			SubmittedAt: at(min),
+		}
+	}
+
+	stubListReviews := func(reviews ...*github.PullRequestReview) func(owner, repo string, num int) ([]*github.PullRequestReview, error) {
+		return func(owner, repo string, num int) ([]*github.PullRequestReview, error) {
+			return reviews, nil
+		}
+	}
+
+	testcases := []struct {
+		cmd      *Action
+		expected string
+	}{
+		{
+			cmd: &Action{
+				MinApprovals: 2,
+				ListReviews: stubListReviews(
+					review("alice", ReviewStateApproved, "head", 1),
+					review("bob", ReviewStateApproved, "head", 2),
+				),
+			},
+			expected: "",
+		},
+		{
+			cmd: &Action{
+				MinApprovals: 2,
+				ListReviews: stubListReviews(
+					review("alice", ReviewStateApproved, "head", 1),
+					review("bob", "COMMENTED", "head", 2),
+					review("carol", "DISMISSED", "head", 3),
+				),
+			},
+			expected: "1 check(s) failed:\n* not enough approvals: expected at least 2, got 1",
+		},
+		{
+			// A comment after an approval doesn't revoke the approval
+			cmd: &Action{
+				RequireApprovalsBy: []string{"alice"},
+				ListReviews: stubListReviews(
+					review("alice", ReviewStateApproved, "head", 1),
+					review("alice", "COMMENTED", "head", 2),
+				),
+			},
+			expected: "",
+		},
+		{
+			cmd: &Action{
+				RequireApprovalsBy: []string{"alice", "bob"},
+				ListReviews: stubListReviews(
+					review("alice", ReviewStateChangesRequested, "head", 1),
+					review("alice", ReviewStateApproved, "head", 2),
+					review("bob", ReviewStateApproved, "head", 3),
+					review("bob", ReviewStateChangesRequested, "head", 4),
+				),
+			},
+			expected: "2 check(s) failed:\n* missing approval by bob\n* changes requested by bob",
+		},
+		{
+			// Outstanding change requests block the pull request even when another requirement passed
+			cmd: &Action{
+				Labels:       []string{"v1"},
+				MinApprovals: 1,
+				ListReviews: stubListReviews(
+					review("alice", ReviewStateApproved, "head", 1),
+					review("bob", ReviewStateChangesRequested, "head", 2),
+				),
+			},
+			expected: "1 check(s) failed:\n* changes requested by bob",
+		},
+		{
+			cmd: &Action{
+				MinApprovals:         1,
+				IgnoreStaleApprovals: true,
+				ListReviews: stubListReviews(
+					review("alice", ReviewStateApproved, "old", 1),
+				),
+			},
+			expected: "1 check(s) failed:\n* not enough approvals: expected at least 1, got 0",
+		},
+		{
+			cmd: &Action{
+				RuleFlags: []string{`reviewed=min_approvals(1) && !changes_requested()`},
+				ListReviews: stubListReviews(
+					review("alice", ReviewStateApproved, "head", 1),
+					review("bob", ReviewStateChangesRequested, "head", 2),
+				),
+			},
+			expected: `[fail] !changes_requested()`,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		tc.cmd.RequireAny = true
+		tc.cmd.NoteRegex = DefaultNoteRegex
+		tc.cmd.GetPullRequestBody = stubPRBody
+
+		input := &github.PullRequest{
+			Head:   &github.PullRequestBranch{SHA: github.String("head")},
+			Labels: []*github.Label{{Name: github.String("v1")}},
+		}
+
+		err := tc.cmd.HandlePullRequest("myuser", "myrepo", input)
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
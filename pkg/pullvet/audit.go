@@ -0,0 +1,202 @@
+package pullvet
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+)
+
+// AuditEntry is the result of evaluating an open pull request in the -all-open mode
+type AuditEntry struct {
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Passed bool   `json:"passed"`
+	// Failures are the reasons the pull request failed pullvet
+	Failures []string `json:"failures,omitempty"`
+	// Error is the error occurred while evaluating the pull request, if any
+	Error string `json:"error,omitempty"`
+}
+
+// summary describes the failures of the pull request in a line
+func (e AuditEntry) summary() string {
+	if e.Error != "" {
+		return "error: " + e.Error
+	}
+	var lines []string
+	for _, f := range e.Failures {
+		lines = append(lines, strings.SplitN(f, "\n", 2)[0])
+	}
+	return strings.Join(lines, "; ")
+}
+
+func (e AuditEntry) result() string {
+	switch {
+	case e.Error != "":
+		return "error"
+	case e.Passed:
+		return "passed"
+	default:
+		return "failed"
+	}
+}
+
+// Audit evaluates every open pull request in Repos and writes the consolidated report.
+// It fails when any of the pull requests failed pullvet.
+func (c *Action) Audit() error {
+	entries, err := c.audit()
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeAudit(os.Stdout, entries); err != nil {
+		return err
+	}
+
+	var failed int
+	for _, e := range entries {
+		if !e.Passed {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d pull request(s) failed pullvet", failed, len(entries))
+	}
+
+	return nil
+}
+
+// audit evaluates every open pull request in Repos.
+// An error evaluating a pull request is recorded in the entry so that the remaining pull requests are still evaluated.
+func (c *Action) audit() ([]AuditEntry, error) {
+	repos := c.Repos
+	if len(repos) == 0 {
+		owner, repo, err := actions.OwnerRepo()
+		if err != nil {
+			return nil, err
+		}
+		repos = []string{owner + "/" + repo}
+	}
+
+	var entries []AuditEntry
+
+	for _, ownerRepo := range repos {
+		parts := strings.Split(ownerRepo, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("unexpected format of repository %q: expected OWNER/REPO", ownerRepo)
+		}
+		owner, repo := parts[0], parts[1]
+
+		pulls, err := c.ListPullRequests(owner, repo, c.Base)
+		if err != nil {
+			return nil, fmt.Errorf("listing pull requests in %s: %v", ownerRepo, err)
+		}
+
+		log.Printf("Auditing %d open pull request(s) in %s", len(pulls), ownerRepo)
+
+		for _, pr := range pulls {
+			entry := AuditEntry{
+				Repo:   ownerRepo,
+				Number: pr.GetNumber(),
+				Title:  pr.GetTitle(),
+				URL:    pr.GetHTMLURL(),
+			}
+
+			report, err := c.vet(owner, repo, pr)
+			if err == nil {
+				err = c.publish(owner, repo, pr, report)
+			}
+
+			if err != nil {
+				log.Printf("Failed evaluating %s#%d: %v", ownerRepo, pr.GetNumber(), err)
+				entry.Error = err.Error()
+			} else {
+				entry.Passed = report.Passed
+				entry.Failures = report.Failures
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// writeAudit writes the audit entries in the format specified by Output
+func (c *Action) writeAudit(w io.Writer, entries []AuditEntry) error {
+	switch c.Output {
+	case OutputText, "":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "PULL REQUEST\tRESULT\tTITLE\tFAILURES")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%s#%d\t%s\t%s\t%s\n", e.Repo, e.Number, e.result(), e.Title, e.summary())
+		}
+		return tw.Flush()
+	case OutputJSON:
+		if entries == nil {
+			entries = []AuditEntry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case OutputMarkdown:
+		var b strings.Builder
+		b.WriteString("| | Pull request | Title | Failures |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for _, e := range entries {
+			mark := ":white_check_mark:"
+			if !e.Passed {
+				mark = ":x:"
+			}
+			fmt.Fprintf(&b, "| %s | [%s#%d](%s) | %s | %s |\n", mark, e.Repo, e.Number, e.URL, markdownCell(e.Title), markdownCell(e.summary()))
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	case OutputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"repo", "number", "title", "url", "result", "failures"}); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := cw.Write([]string{e.Repo, strconv.Itoa(e.Number), e.Title, e.URL, e.result(), e.summary()}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported output %q: expected any of %q, %q, %q and %q", c.Output, OutputText, OutputJSON, OutputMarkdown, OutputCSV)
+	}
+}
+
+func ListPullRequests(owner, repo, base string) ([]*github.PullRequest, error) {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return actions.ListOpenPullRequests(client, owner, repo, base)
+}
+
+func CreateStatus(owner, repo, ref string, status *github.RepoStatus) error {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.Repositories.CreateStatus(context.Background(), owner, repo, ref, status)
+
+	return err
+}
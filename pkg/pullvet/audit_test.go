@@ -0,0 +1,98 @@
+package pullvet
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestAudit(t *testing.T) {
+	var statuses []string
+
+	cmd := &Action{
+		AllOpen:       true,
+		Base:          "master",
+		Repos:         []string{"myorg/a", "myorg/b"},
+		RequireAll:    true,
+		Labels:        []string{"v1"},
+		NoteRegex:     DefaultNoteRegex,
+		StatusContext: "pullvet",
+		GetPullRequestBody: func(owner, repo string, num int) (string, error) {
+			if num == 3 {
+				return "", fmt.Errorf("not found")
+			}
+			return "", nil
+		},
+		ListPullRequests: func(owner, repo, base string) ([]*github.PullRequest, error) {
+			if base != "master" {
+				return nil, fmt.Errorf("unexpected base %q", base)
+			}
+			switch repo {
+			case "a":
+				return []*github.PullRequest{
+					{Number: github.Int(1), Title: github.String("one"), Head: &github.PullRequestBranch{SHA: github.String("sha1")}, Labels: []*github.Label{{Name: github.String("v1")}}},
+					{Number: github.Int(2), Title: github.String("two"), Head: &github.PullRequestBranch{SHA: github.String("sha2")}},
+				}, nil
+			default:
+				return []*github.PullRequest{
+					{Number: github.Int(3), Title: github.String("three"), Head: &github.PullRequestBranch{SHA: github.String("sha3")}},
+				}, nil
+			}
+		},
+		CreateStatus: func(owner, repo, ref string, status *github.RepoStatus) error {
+			statuses = append(statuses, fmt.Sprintf("%s/%s@%s=%s", owner, repo, ref, status.GetState()))
+			return nil
+		},
+	}
+
+	entries, err := cmd.audit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []string
+	for _, e := range entries {
+		results = append(results, fmt.Sprintf("%s#%d=%s", e.Repo, e.Number, e.result()))
+	}
+
+	expectedResults := []string{"myorg/a#1=passed", "myorg/a#2=failed", "myorg/b#3=error"}
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("unexpected results: expected=%v, got=%v", expectedResults, results)
+	}
+
+	expectedStatuses := []string{"myorg/a@sha1=success", "myorg/a@sha2=failure"}
+	if !reflect.DeepEqual(statuses, expectedStatuses) {
+		t.Errorf("unexpected statuses: expected=%v, got=%v", expectedStatuses, statuses)
+	}
+
+	var buf bytes.Buffer
+
+	cmd.Output = OutputCSV
+	if err := cmd.writeAudit(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCSV := `repo,number,title,url,result,failures
+myorg/a,1,one,,passed,
+myorg/a,2,two,,failed,missing label: v1
+myorg/b,3,three,,error,error: not found
+`
+	if buf.String() != expectedCSV {
+		t.Errorf("unexpected csv: expected=%q, got=%q", expectedCSV, buf.String())
+	}
+
+	buf.Reset()
+
+	cmd.Output = OutputText
+	if err := cmd.writeAudit(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "myorg/a#2     failed  two    missing label: v1") {
+		t.Errorf("unexpected text: %s", buf.String())
+	}
+}
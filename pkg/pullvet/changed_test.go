@@ -0,0 +1,79 @@
+package pullvet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestChangedRules(t *testing.T) {
+	stubPRBody := func(owner, repo string, num int) (string, error) {
+		return "", nil
+	}
+
+	stubListFiles := func(paths ...string) func(owner, repo string, num int) ([]*github.CommitFile, error) {
+		return func(owner, repo string, num int) ([]*github.CommitFile, error) {
+			var files []*github.CommitFile
+			for _, p := range paths {
+				files = append(files, &github.CommitFile{Filename: github.String(p)})
+			}
+			return files, nil
+		}
+	}
+
+	opsApproved := []string{`deploy/**=label("ops-approved")`}
+
+	testcases := []struct {
+		cmd      *Action
+		expected string
+	}{
+		{
+			cmd:      &Action{WhenChangedFlags: opsApproved, ListFiles: stubListFiles("README.md", "pkg/foo.go")},
+			expected: "",
+		},
+		{
+			cmd:      &Action{WhenChangedFlags: opsApproved, ListFiles: stubListFiles("README.md", "deploy/prod/values.yaml")},
+			expected: "1 check(s) failed:\n* rule \"when changed deploy/**\" failed\n    [fail] label(\"ops-approved\"): labels were []",
+		},
+		{
+			cmd: &Action{
+				Rules:     []Rule{{Name: "migration", When: `changed("migrations/")`, Expr: `note("migration")`}},
+				ListFiles: stubListFiles("migrations/001_init.sql"),
+			},
+			expected: "1 check(s) failed:\n* rule \"migration\" failed\n    [fail] note(\"migration\"): notes were []",
+		},
+		{
+			cmd: &Action{
+				RuleFlags: []string{`docs=changed_only("**/*.md", "docs/") || label("reviewed")`},
+				ListFiles: stubListFiles("README.md", "docs/img/arch.png"),
+			},
+			expected: "",
+		},
+		{
+			cmd: &Action{
+				RuleFlags: []string{`docs=changed_only("**/*.md", "docs/") || label("reviewed")`},
+				ListFiles: stubListFiles("README.md", "main.go"),
+			},
+			expected: "[fail] changed_only(\"**/*.md\", \"docs/\"): other files changed: [\"main.go\"]",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		tc.cmd.RequireAny = true
+		tc.cmd.NoteRegex = DefaultNoteRegex
+		tc.cmd.GetPullRequestBody = stubPRBody
+
+		err := tc.cmd.HandlePullRequest("myuser", "myrepo", &github.PullRequest{})
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package pullvet
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/variantdev/go-actions/pkg/glob"
+)
+
+// CodeownersPaths are the paths to look for the CODEOWNERS file at, in the order GitHub looks for it
+var CodeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Codeowners is the parsed CODEOWNERS file
+type Codeowners struct {
+	Entries []CodeownersEntry
+}
+
+// CodeownersEntry is a line of the CODEOWNERS file, like `/deploy/ @myorg/ops @alice`
+type CodeownersEntry struct {
+	Pattern string
+	// Owners are either `@login`, `@org/team` or email addresses
+	Owners []string
+
+	regexps []*regexp.Regexp
+}
+
+// ParseCodeowners parses the content of the CODEOWNERS file
+func ParseCodeowners(content string) (*Codeowners, error) {
+	co := &Codeowners{}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+
+	var lineno int
+	for scanner.Scan() {
+		lineno++
+
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		rs, err := compileCodeownersPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+
+		co.Entries = append(co.Entries, CodeownersEntry{Pattern: fields[0], Owners: fields[1:], regexps: rs})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return co, nil
+}
+
+// compileCodeownersPattern translates the gitignore-like pattern into globs.
+// A pattern without a slash other than the trailing one matches at any depth,
+// and a pattern matching a directory matches everything under it, except patterns like `docs/*`.
+func compileCodeownersPattern(pattern string) ([]*regexp.Regexp, error) {
+	p := pattern
+
+	anchored := strings.HasPrefix(p, "/") || strings.Contains(strings.TrimSuffix(p, "/"), "/")
+
+	p = strings.TrimPrefix(p, "/")
+
+	if !anchored && !strings.HasPrefix(p, "**/") {
+		p = "**/" + p
+	}
+
+	globs := []string{p}
+	if !strings.HasSuffix(p, "/") && !strings.HasSuffix(p, "/*") {
+		globs = append(globs, p+"/")
+	}
+
+	var rs []*regexp.Regexp
+	for _, g := range globs {
+		r, err := glob.Compile(g)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+
+	return rs, nil
+}
+
+// Match returns the entry for the path. The last matching entry wins, as in GitHub
+func (co *Codeowners) Match(path string) *CodeownersEntry {
+	for i := len(co.Entries) - 1; i >= 0; i-- {
+		if matchAny(co.Entries[i].regexps, path) {
+			return &co.Entries[i]
+		}
+	}
+	return nil
+}
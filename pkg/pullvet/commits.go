@@ -0,0 +1,198 @@
+package pullvet
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// conventionalRegex matches the header of a Conventional Commits message, like `feat(api)!: drop v1 endpoints`
+var conventionalRegex = regexp.MustCompile(`^([a-zA-Z]+)(\([^()\r\n]+\))?(!)?: \S`)
+
+// signoffRegex matches the DCO trailer, like `Signed-off-by: Jane Doe <jane@example.com>`
+var signoffRegex = regexp.MustCompile(`(?m)^Signed-off-by: .*<([^<>\s]+)>\s*$`)
+
+// commits returns the commits in the pull request, excluding merge commits.
+// Merge commits are usually created by GitHub on updating the branch, so conventions don't apply to them.
+func (f *facts) commits() ([]*github.RepositoryCommit, error) {
+	if f.prCommits != nil {
+		return f.prCommits, nil
+	}
+
+	all, err := f.action.ListCommits(f.owner, f.repo, f.pr.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []*github.RepositoryCommit{}
+	for _, c := range all {
+		if len(c.Parents) > 1 {
+			continue
+		}
+		commits = append(commits, c)
+	}
+
+	f.prCommits = commits
+
+	return commits, nil
+}
+
+// subject returns the first line of the commit message
+func subject(msg string) string {
+	return strings.SplitN(normalizeNewlines(msg), "\n", 2)[0]
+}
+
+// isConventional returns true when the header follows Conventional Commits, and its type is any of types if given
+func isConventional(header string, types []string) bool {
+	m := conventionalRegex.FindStringSubmatch(header)
+	if m == nil {
+		return false
+	}
+	return len(types) == 0 || contains(types, m[1])
+}
+
+// commitsPredicate returns the predicate that holds when every commit satisfies ok.
+// The detail names the offending commits by short SHAs.
+func commitsPredicate(ok func(c *github.RepositoryCommit) bool) predicateFunc {
+	return func(f *facts) (bool, string, error) {
+		commits, err := f.commits()
+		if err != nil {
+			return false, "", err
+		}
+		var offending []string
+		for _, c := range commits {
+			if !ok(c) {
+				sha := c.GetSHA()
+				if len(sha) > 7 {
+					sha = sha[:7]
+				}
+				offending = append(offending, fmt.Sprintf("%s %q", sha, subject(c.GetCommit().GetMessage())))
+			}
+		}
+		if len(offending) == 0 {
+			return true, "", nil
+		}
+		return false, "offending commits: " + strings.Join(offending, ", "), nil
+	}
+}
+
+func titleMatchPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	rs, err := compileRegexps(args)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		title := f.pr.GetTitle()
+		if matchAny(rs, title) {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("title was %q", title), nil
+	}, nil
+}
+
+func conventionalTitlePredicate(args []string) (predicateFunc, error) {
+	return func(f *facts) (bool, string, error) {
+		title := f.pr.GetTitle()
+		if isConventional(title, args) {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("title was %q", title), nil
+	}, nil
+}
+
+func conventionalCommitsPredicate(args []string) (predicateFunc, error) {
+	return commitsPredicate(func(c *github.RepositoryCommit) bool {
+		return isConventional(subject(c.GetCommit().GetMessage()), args)
+	}), nil
+}
+
+func maxSubjectLengthPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return commitsPredicate(func(c *github.RepositoryCommit) bool {
+		return len([]rune(subject(c.GetCommit().GetMessage()))) <= n
+	}), nil
+}
+
+func commitsMatchPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	rs, err := compileRegexps(args)
+	if err != nil {
+		return nil, err
+	}
+	return commitsPredicate(func(c *github.RepositoryCommit) bool {
+		return matchAny(rs, normalizeNewlines(c.GetCommit().GetMessage()))
+	}), nil
+}
+
+// signedOffPredicate holds when every commit has the Signed-off-by trailer with the email address of the commit author
+func signedOffPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return commitsPredicate(func(c *github.RepositoryCommit) bool {
+		email := c.GetCommit().GetAuthor().GetEmail()
+		for _, m := range signoffRegex.FindAllStringSubmatch(normalizeNewlines(c.GetCommit().GetMessage()), -1) {
+			if strings.EqualFold(m[1], email) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// conventionRules returns the rules for the title and commit message conventions given via flags
+func (c *Action) conventionRules() []Rule {
+	var rules []Rule
+
+	add := func(name, pred string, args ...string) {
+		var quoted []string
+		for _, a := range args {
+			quoted = append(quoted, quoteArg(a))
+		}
+		rules = append(rules, Rule{Name: name, Expr: pred + "(" + strings.Join(quoted, ", ") + ")"})
+	}
+
+	if len(c.TitleMatches) > 0 {
+		add("title", "title_match", c.TitleMatches...)
+	}
+
+	if c.ConventionalTitle {
+		add("conventional title", "conventional_title", c.ConventionalTypes...)
+	}
+
+	if c.ConventionalCommits {
+		add("conventional commits", "conventional_commits", c.ConventionalTypes...)
+	}
+
+	if c.MaxSubjectLength > 0 {
+		add("commit subject length", "max_subject_length", strconv.Itoa(c.MaxSubjectLength))
+	}
+
+	if len(c.CommitMatches) > 0 {
+		add("commit messages", "commits_match", c.CommitMatches...)
+	}
+
+	if c.RequireIssueKey != "" {
+		add("issue key", "commits_match", c.RequireIssueKey)
+	}
+
+	if c.RequireSignoff {
+		add("signed off", "signed_off")
+	}
+
+	return rules
+}
@@ -0,0 +1,103 @@
+package pullvet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestConventions(t *testing.T) {
+	stubPRBody := func(owner, repo string, num int) (string, error) {
+		return "", nil
+	}
+
+	commit := func(sha, email, msg string, parents int) *github.RepositoryCommit {
+		c := &github.RepositoryCommit{
+			SHA: github.String(sha),
+			Commit: &github.Commit{
+				Author:  &github.CommitAuthor{Email: github.String(email)},
+				Message: github.String(msg),
+			},
+		}
+		for i := 0; i < parents; i++ {
+			c.Parents = append(c.Parents, github.Commit{})
+		}
+		return c
+	}
+
+	commits := []*github.RepositoryCommit{
+		commit("1111111aaaa", "alice@example.com", "feat(api)!: drop v1 endpoints\n\nABC-123\n\nSigned-off-by: Alice <alice@example.com>", 1),
+		commit("2222222bbbb", "bob@example.com", "fix stuff\r\n\r\nSigned-off-by: Alice <alice@example.com>", 1),
+		commit("3333333cccc", "bob@example.com", "Merge branch 'master' into feat", 2),
+	}
+
+	stubListCommits := func(owner, repo string, num int) ([]*github.RepositoryCommit, error) {
+		return commits, nil
+	}
+
+	testcases := []struct {
+		cmd      *Action
+		title    string
+		expected string
+	}{
+		{
+			cmd:      &Action{ConventionalTitle: true, ConventionalTypes: []string{"feat", "fix"}},
+			title:    "feat(api): add v2 endpoints",
+			expected: "",
+		},
+		{
+			cmd:      &Action{ConventionalTitle: true, ConventionalTypes: []string{"feat", "fix"}},
+			title:    "chore: bump deps",
+			expected: "1 check(s) failed:\n* rule \"conventional title\" failed\n    [fail] conventional_title(\"feat\", \"fix\"): title was \"chore: bump deps\"",
+		},
+		{
+			cmd:      &Action{TitleMatches: []string{`^\[[A-Z]+-[0-9]+\] `}},
+			title:    "[ABC-123] Add v2 endpoints",
+			expected: "",
+		},
+		{
+			cmd:      &Action{ConventionalCommits: true},
+			expected: `[fail] conventional_commits(): offending commits: 2222222 "fix stuff"`,
+		},
+		{
+			cmd:      &Action{MaxSubjectLength: 10},
+			expected: `[fail] max_subject_length("10"): offending commits: 1111111 "feat(api)!: drop v1 endpoints"`,
+		},
+		{
+			cmd:      &Action{RequireIssueKey: "[A-Z]+-[0-9]+"},
+			expected: `[fail] commits_match("[A-Z]+-[0-9]+"): offending commits: 2222222 "fix stuff"`,
+		},
+		{
+			cmd:      &Action{RequireSignoff: true},
+			expected: `[fail] signed_off(): offending commits: 2222222 "fix stuff"`,
+		},
+		{
+			cmd:      &Action{RuleFlags: []string{`dco=signed_off() || label("trivial")`}},
+			expected: `rule "dco" failed`,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		tc.cmd.RequireAny = true
+		tc.cmd.NoteRegex = DefaultNoteRegex
+		tc.cmd.GetPullRequestBody = stubPRBody
+		tc.cmd.ListCommits = stubListCommits
+
+		input := &github.PullRequest{
+			Title: github.String(tc.title),
+		}
+
+		err := tc.cmd.HandlePullRequest("myuser", "myrepo", input)
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
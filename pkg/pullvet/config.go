@@ -0,0 +1,189 @@
+package pullvet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/google/go-github/v28/github"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ConfigSourceBase reads the config file from the base branch of the pull request,
+	// so that the author of the pull request can't weaken the rules in the pull request itself
+	ConfigSourceBase = "base"
+	// ConfigSourceWorktree reads the config file from the working tree
+	ConfigSourceWorktree = "worktree"
+)
+
+// Config is the content of the pullvet config file, like:
+//
+//	rulesets:
+//	- name: default
+//	  rules:
+//	  - name: sized
+//	    message: Add a size label
+//	    expr: label_match("size/.+")
+//	- name: release
+//	  branches: ["^release-.+"]
+//	  rules:
+//	  - name: milestoned
+//	    expr: milestone_match("^v.+")
+type Config struct {
+	RuleSets []RuleSet `yaml:"rulesets"`
+}
+
+// RuleSet is a named set of rules applied to pull requests whose base branch matches any of Branches
+type RuleSet struct {
+	Name string `yaml:"name"`
+	// Branches are regexp patterns to match the base branch against. The rule set applies to every pull request when empty
+	Branches []string `yaml:"branches"`
+	// When is the expression that limits the pull requests every rule in the rule set applies to, in addition to the When of each rule
+	When  string `yaml:"when"`
+	Rules []Rule `yaml:"rules"`
+}
+
+func ParseConfig(bs []byte) (*Config, error) {
+	var conf Config
+
+	if err := yaml.UnmarshalStrict(bs, &conf); err != nil {
+		return nil, err
+	}
+
+	for _, rs := range conf.RuleSets {
+		if rs.Name == "" {
+			return nil, fmt.Errorf("missing name of rule set")
+		}
+
+		if _, err := compileRegexps(rs.Branches); err != nil {
+			return nil, fmt.Errorf("rule set %q: %v", rs.Name, err)
+		}
+
+		if rs.When != "" {
+			if _, err := ParseExpr(rs.When); err != nil {
+				return nil, fmt.Errorf("rule set %q: when: %v", rs.Name, err)
+			}
+		}
+
+		for _, r := range rs.Rules {
+			if r.Name == "" {
+				return nil, fmt.Errorf("rule set %q: missing name of rule", rs.Name)
+			}
+
+			if _, err := ParseExpr(r.Expr); err != nil {
+				return nil, fmt.Errorf("rule set %q: rule %q: %v", rs.Name, r.Name, err)
+			}
+
+			if r.When != "" {
+				if _, err := ParseExpr(r.When); err != nil {
+					return nil, fmt.Errorf("rule set %q: rule %q: when: %v", rs.Name, r.Name, err)
+				}
+			}
+
+			if r.Fix != nil && r.Fix.MilestoneMatch != "" {
+				if _, err := compileRegexps([]string{r.Fix.MilestoneMatch}); err != nil {
+					return nil, fmt.Errorf("rule set %q: rule %q: fix: %v", rs.Name, r.Name, err)
+				}
+			}
+		}
+	}
+
+	return &conf, nil
+}
+
+// Select returns the rule sets that apply to the base branch
+func (conf *Config) Select(base string) []RuleSet {
+	var selected []RuleSet
+
+	for _, rs := range conf.RuleSets {
+		if len(rs.Branches) == 0 {
+			selected = append(selected, rs)
+			continue
+		}
+
+		// Patterns are already validated in ParseConfig
+		branches, _ := compileRegexps(rs.Branches)
+
+		if matchAny(branches, base) {
+			selected = append(selected, rs)
+		}
+	}
+
+	return selected
+}
+
+// readFile reads the file from either the base branch or the working tree, according to ConfigSource
+func (c *Action) readFile(owner, repo string, pullRequest *github.PullRequest, path string) ([]byte, error) {
+	switch c.ConfigSource {
+	case ConfigSourceBase, "":
+		if owner == "" {
+			return nil, fmt.Errorf("unable to read %s from the base branch: missing repository owner", path)
+		}
+
+		base := pullRequest.GetBase().GetRef()
+
+		content, err := c.GetFileContent(owner, repo, base, path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s from branch %q: %v", path, base, err)
+		}
+
+		return []byte(content), nil
+	case ConfigSourceWorktree:
+		return ioutil.ReadFile(path)
+	default:
+		return nil, fmt.Errorf("unsupported config source %q: expected either %q or %q", c.ConfigSource, ConfigSourceBase, ConfigSourceWorktree)
+	}
+}
+
+// loadConfig reads the config file from either the base branch or the working tree, according to ConfigSource
+func (c *Action) loadConfig(owner, repo string, pullRequest *github.PullRequest) (*Config, error) {
+	bs, err := c.readFile(owner, repo, pullRequest, c.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := ParseConfig(bs)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", c.ConfigFile, err)
+	}
+
+	return conf, nil
+}
+
+// configRules returns the rules of the rule sets in the config file that apply to the pull request
+func (c *Action) configRules(owner, repo string, pullRequest *github.PullRequest) ([]Rule, error) {
+	if c.ConfigFile == "" {
+		return nil, nil
+	}
+
+	conf, err := c.loadConfig(owner, repo, pullRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+
+	for _, rs := range conf.Select(pullRequest.GetBase().GetRef()) {
+		log.Printf("Applying rule set %q", rs.Name)
+
+		for _, r := range rs.Rules {
+			r.When = joinConditions(rs.When, r.When)
+			rules = append(rules, r)
+		}
+	}
+
+	return rules, nil
+}
+
+// joinConditions returns the expression that holds when both expressions hold. An empty expression always holds
+func joinConditions(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return "(" + a + ") && (" + b + ")"
+	}
+}
@@ -0,0 +1,128 @@
+package pullvet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+const testConfig = `
+rulesets:
+- name: default
+  rules:
+  - name: sized
+    message: Add a size label
+    expr: label_match("size/.+")
+- name: release
+  branches: ["^release-.+"]
+  rules:
+  - name: milestoned
+    expr: milestone_match("^v.+")
+`
+
+func TestConfig(t *testing.T) {
+	stubPRBody := func(owner, repo string, num int) (string, error) {
+		return "", nil
+	}
+
+	stubFileContent := func(files map[string]string) func(owner, repo, ref, path string) (string, error) {
+		return func(owner, repo, ref, path string) (string, error) {
+			content, ok := files[ref+":"+path]
+			if !ok {
+				return "", fmt.Errorf("404 Not Found")
+			}
+			return content, nil
+		}
+	}
+
+	files := map[string]string{
+		"master:.github/pullvet.yaml":    testConfig,
+		"release-1:.github/pullvet.yaml": testConfig,
+	}
+
+	testcases := []struct {
+		input    *github.PullRequest
+		expected string
+	}{
+		{
+			input: &github.PullRequest{
+				Base:   &github.PullRequestBranch{Ref: github.String("master")},
+				Labels: []*github.Label{{Name: github.String("size/s")}},
+			},
+			expected: "",
+		},
+		{
+			input: &github.PullRequest{
+				Base: &github.PullRequestBranch{Ref: github.String("master")},
+			},
+			expected: "1 check(s) failed:\n* rule \"sized\" failed: Add a size label",
+		},
+		{
+			input: &github.PullRequest{
+				Base:   &github.PullRequestBranch{Ref: github.String("release-1")},
+				Labels: []*github.Label{{Name: github.String("size/s")}},
+			},
+			expected: "1 check(s) failed:\n* rule \"milestoned\" failed",
+		},
+		{
+			input: &github.PullRequest{
+				Base: &github.PullRequestBranch{Ref: github.String("develop")},
+			},
+			expected: `reading .github/pullvet.yaml from branch "develop": 404 Not Found`,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		cmd := &Action{
+			RequireAny:         true,
+			ConfigFile:         ".github/pullvet.yaml",
+			ConfigSource:       ConfigSourceBase,
+			NoteRegex:          DefaultNoteRegex,
+			GetPullRequestBody: stubPRBody,
+			GetFileContent:     stubFileContent(files),
+		}
+
+		err := cmd.HandlePullRequest("myuser", "myrepo", tc.input)
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "rulesets:\n- rules: []\n",
+			expected: "missing name of rule set",
+		},
+		{
+			input:    "rulesets:\n- name: a\n  rules:\n  - name: b\n    expr: foo()\n",
+			expected: `rule set "a": rule "b": unknown predicate "foo"`,
+		},
+		{
+			input:    "rulesets:\n- name: a\n  unknown: b\n",
+			expected: "field unknown not found",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		_, err := ParseConfig([]byte(tc.input))
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package pullvet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is a boolean expression over predicates on the pull request, like:
+//
+//	(label_match("size/.+") && milestone_match("v.+")) || label("hotfix")
+//
+// Operators are `!`, `&&` and `||`, or `not`, `and` and `or`, in the order of precedence.
+// String arguments can be quoted with either `"` or `'`.
+type Expr interface {
+	String() string
+
+	eval(f *facts) (*evaluation, error)
+}
+
+// evaluation is the result of evaluating an expression, forming a tree that mirrors the expression
+type evaluation struct {
+	expr     Expr
+	passed   bool
+	detail   string
+	children []*evaluation
+}
+
+type andExpr struct {
+	operands []Expr
+}
+
+type orExpr struct {
+	operands []Expr
+}
+
+type notExpr struct {
+	operand Expr
+}
+
+type callExpr struct {
+	name string
+	args []string
+	pred predicateFunc
+}
+
+func (e *andExpr) String() string {
+	var ops []string
+	for _, o := range e.operands {
+		if _, ok := o.(*orExpr); ok {
+			ops = append(ops, "("+o.String()+")")
+		} else {
+			ops = append(ops, o.String())
+		}
+	}
+	return strings.Join(ops, " && ")
+}
+
+func (e *orExpr) String() string {
+	var ops []string
+	for _, o := range e.operands {
+		ops = append(ops, o.String())
+	}
+	return strings.Join(ops, " || ")
+}
+
+func (e *notExpr) String() string {
+	switch e.operand.(type) {
+	case *andExpr, *orExpr:
+		return "!(" + e.operand.String() + ")"
+	}
+	return "!" + e.operand.String()
+}
+
+func (e *callExpr) String() string {
+	var args []string
+	for _, a := range e.args {
+		args = append(args, quoteArg(a))
+	}
+	return e.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Every operand is evaluated even after the result is determined, so that the report shows every failed branch
+func (e *andExpr) eval(f *facts) (*evaluation, error) {
+	ev := &evaluation{expr: e, passed: true}
+	for _, o := range e.operands {
+		child, err := o.eval(f)
+		if err != nil {
+			return nil, err
+		}
+		ev.passed = ev.passed && child.passed
+		ev.children = append(ev.children, child)
+	}
+	return ev, nil
+}
+
+func (e *orExpr) eval(f *facts) (*evaluation, error) {
+	ev := &evaluation{expr: e}
+	for _, o := range e.operands {
+		child, err := o.eval(f)
+		if err != nil {
+			return nil, err
+		}
+		ev.passed = ev.passed || child.passed
+		ev.children = append(ev.children, child)
+	}
+	return ev, nil
+}
+
+func (e *notExpr) eval(f *facts) (*evaluation, error) {
+	child, err := e.operand.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	return &evaluation{expr: e, passed: !child.passed, children: []*evaluation{child}}, nil
+}
+
+func (e *callExpr) eval(f *facts) (*evaluation, error) {
+	passed, detail, err := e.pred(f)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating %s: %v", e.String(), err)
+	}
+	return &evaluation{expr: e, passed: passed, detail: detail}, nil
+}
+
+// format renders the evaluation tree, one node per line, marking each node as passed or failed
+func (ev *evaluation) format(indent string) string {
+	mark := "[fail]"
+	if ev.passed {
+		mark = "[pass]"
+	}
+
+	line := fmt.Sprintf("%s%s %s", indent, mark, ev.expr.String())
+	if ev.detail != "" {
+		line += ": " + ev.detail
+	}
+
+	lines := []string{line}
+	for _, c := range ev.children {
+		lines = append(lines, c.format(indent+"  "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// failureDetails returns the failed calls in the evaluation, along with the details of the actual values
+func (ev *evaluation) failureDetails() []string {
+	if ev.passed {
+		return nil
+	}
+
+	var details []string
+	for _, c := range ev.children {
+		details = append(details, c.failureDetails()...)
+	}
+
+	// Leaves, and negations whose operand passed, fail by themselves
+	if len(details) == 0 {
+		d := ev.expr.String()
+		if ev.detail != "" {
+			d += ": " + ev.detail
+		}
+		details = append(details, d)
+	}
+
+	return details
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+
+	i := 0
+	for i < len(src) {
+		ch := src[i]
+
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case ch == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case ch == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+		case ch == '!':
+			tokens = append(tokens, token{kind: tokNot, text: "!", pos: i})
+			i++
+		case strings.HasPrefix(src[i:], "&&"):
+			tokens = append(tokens, token{kind: tokAnd, text: "&&", pos: i})
+			i += 2
+		case strings.HasPrefix(src[i:], "||"):
+			tokens = append(tokens, token{kind: tokOr, text: "||", pos: i})
+			i += 2
+		case ch == '"' || ch == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(src) {
+				if src[i] == ch {
+					closed = true
+					i++
+					break
+				}
+				// Only the quote and the backslash are escapable so that regexps like `\d` can be written as-is
+				if src[i] == '\\' && i+1 < len(src) && (src[i+1] == ch || src[i+1] == '\\') {
+					b.WriteByte(src[i+1])
+					i += 2
+					continue
+				}
+				b.WriteByte(src[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			tokens = append(tokens, token{kind: tokString, text: b.String(), pos: start})
+		case isIdentByte(ch):
+			start := i
+			for i < len(src) && isIdentByte(src[i]) {
+				i++
+			}
+			word := src[start:i]
+			switch word {
+			case "and":
+				tokens = append(tokens, token{kind: tokAnd, text: word, pos: start})
+			case "or":
+				tokens = append(tokens, token{kind: tokOr, text: word, pos: start})
+			case "not":
+				tokens = append(tokens, token{kind: tokNot, text: word, pos: start})
+			default:
+				if word[0] >= '0' && word[0] <= '9' {
+					// Numbers are passed to predicates as strings
+					tokens = append(tokens, token{kind: tokString, text: word, pos: start})
+				} else {
+					tokens = append(tokens, token{kind: tokIdent, text: word, pos: start})
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", ch, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// quoteArg quotes the string so that tokenize reads it back as-is.
+// Unlike strconv.Quote, only the quote and the backslash are escaped, as tokenize unescapes nothing else.
+func quoteArg(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func isIdentByte(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// ParseExpr parses the expression and validates predicate names and arguments
+func ParseExpr(src string) (Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+
+	return e, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []Expr{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		e, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, e)
+	}
+
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &orExpr{operands: operands}, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []Expr{first}
+	for p.peek().kind == tokAnd {
+		p.next()
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, e)
+	}
+
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &andExpr{operands: operands}, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{operand: e}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokLParen:
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokRParen {
+			return nil, fmt.Errorf("expected \")\" at %d, got %q", r.pos, r.text)
+		}
+		return e, nil
+	case tokIdent:
+		var args []string
+
+		if p.peek().kind == tokLParen {
+			p.next()
+
+			if p.peek().kind == tokRParen {
+				p.next()
+			} else {
+				for {
+					a := p.next()
+					if a.kind != tokString {
+						return nil, fmt.Errorf("expected a string argument to %s at %d, got %q", t.text, a.pos, a.text)
+					}
+					args = append(args, a.text)
+
+					sep := p.next()
+					if sep.kind == tokRParen {
+						break
+					}
+					if sep.kind != tokComma {
+						return nil, fmt.Errorf("expected \",\" or \")\" at %d, got %q", sep.pos, sep.text)
+					}
+				}
+			}
+		}
+
+		newPred, ok := predicates[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown predicate %q at %d", t.text, t.pos)
+		}
+
+		pred, err := newPred(args)
+		if err != nil {
+			return nil, fmt.Errorf("%s at %d: %v", t.text, t.pos, err)
+		}
+
+		return &callExpr{name: t.text, args: args, pred: pred}, nil
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
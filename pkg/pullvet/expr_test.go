@@ -0,0 +1,186 @@
+package pullvet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestParseExpr(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    `label("hotfix")`,
+			expected: `label("hotfix")`,
+		},
+		{
+			input:    `(label_match('size/.+') && milestone_match("v.+")) || label("hotfix")`,
+			expected: `label_match("size/.+") && milestone_match("v.+") || label("hotfix")`,
+		},
+		{
+			input:    `label_match('size/.+') and (milestone_match("v.+") or label("hotfix"))`,
+			expected: `label_match("size/.+") && (milestone_match("v.+") || label("hotfix"))`,
+		},
+		{
+			input:    `not (label("a") || label("b")) && !any_milestone`,
+			expected: `!(label("a") || label("b")) && !any_milestone()`,
+		},
+		{
+			input:    `min_approvals(2) || approved_by("alice", "bob")`,
+			expected: `min_approvals("2") || approved_by("alice", "bob")`,
+		},
+		{
+			input:    `milestone_match('v\d+\.\d+') && label('it\'s')`,
+			expected: `milestone_match("v\\d+\\.\\d+") && label("it's")`,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		e, err := ParseExpr(tc.input)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", tc.input, err)
+			continue
+		}
+
+		if got := e.String(); got != tc.expected {
+			t.Errorf("unexpected result: expected=%q, got=%q", tc.expected, got)
+		}
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	for i, arg := range []string{
+		"hotfix",
+		`v\d+\.\d+`,
+		`say "hi"`,
+		`trailing\`,
+		"multi\nline\ttabbed",
+		"caf\u00e9 \u2705",
+		"it's",
+	} {
+		e, err := ParseExpr("label(" + quoteArg(arg) + ")")
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+			continue
+		}
+
+		if actual := e.(*callExpr).args[0]; actual != arg {
+			t.Errorf("testcases[%d]: unexpected argument: expected=%q, got=%q", i, arg, actual)
+		}
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: `label("a"`, expected: `expected "," or ")"`},
+		{input: `label("a") &&`, expected: "unexpected end of expression"},
+		{input: `foo("a")`, expected: `unknown predicate "foo"`},
+		{input: `label()`, expected: "expected at least 1 argument(s), got 0"},
+		{input: `label_match("(")`, expected: "error parsing regexp"},
+		{input: `label("a") label("b")`, expected: `unexpected "label" at 11`},
+		{input: `label("a)`, expected: "unterminated string at 6"},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		_, err := ParseExpr(tc.input)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("unexpected error parsing %q: expected=%q, got=%v", tc.input, tc.expected, err)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	stubPRBody := func(body string) func(owner, repo string, num int) (string, error) {
+		return func(owner, repo string, num int) (string, error) {
+			return body, nil
+		}
+	}
+
+	sized := Rule{
+		Name:    "sized",
+		Message: "needs a size label and a version milestone, or the hotfix label",
+		Expr:    `(label_match("size/.+") && milestone_match("v.+")) || label("hotfix")`,
+	}
+
+	testcases := []struct {
+		cmd      *Action
+		input    *github.PullRequest
+		expected string
+	}{
+		{
+			cmd: &Action{RequireAny: true, Rules: []Rule{sized}, NoteRegex: DefaultNoteRegex, GetPullRequestBody: stubPRBody("")},
+			input: &github.PullRequest{
+				Labels:    []*github.Label{{Name: github.String("size/s")}},
+				Milestone: &github.Milestone{Title: github.String("v1")},
+			},
+			expected: "",
+		},
+		{
+			cmd: &Action{RequireAny: true, Rules: []Rule{sized}, NoteRegex: DefaultNoteRegex, GetPullRequestBody: stubPRBody("")},
+			input: &github.PullRequest{
+				Labels: []*github.Label{{Name: github.String("hotfix")}},
+			},
+			expected: "",
+		},
+		{
+			cmd: &Action{RequireAny: true, Rules: []Rule{sized}, NoteRegex: DefaultNoteRegex, GetPullRequestBody: stubPRBody("")},
+			input: &github.PullRequest{
+				Labels:    []*github.Label{{Name: github.String("size/s")}},
+				Milestone: &github.Milestone{Title: github.String("rel-1")},
+			},
+			expected: `1 check(s) failed:
+* rule "sized" failed: needs a size label and a version milestone, or the hotfix label
+    [fail] label_match("size/.+") && milestone_match("v.+") || label("hotfix")
+      [fail] label_match("size/.+") && milestone_match("v.+")
+        [pass] label_match("size/.+")
+        [fail] milestone_match("v.+"): milestone was "rel-1"
+      [fail] label("hotfix"): labels were ["size/s"]`,
+		},
+		{
+			cmd: &Action{RequireAny: true, Labels: []string{"v1"}, RuleFlags: []string{`noted=note("releasenote")`}, NoteRegex: DefaultNoteRegex, GetPullRequestBody: stubPRBody("releasenote:\n```\nfoo\n```\n")},
+			input: &github.PullRequest{
+				Labels: []*github.Label{{Name: github.String("v2")}},
+			},
+			expected: "1 check(s) failed:\n* missing label: v1",
+		},
+		{
+			cmd: &Action{RequireAny: true, Labels: []string{"v1"}, RuleFlags: []string{`noted=note("releasenote")`}, RuleMessageFlags: []string{"noted=add a release note"}, NoteRegex: DefaultNoteRegex, GetPullRequestBody: stubPRBody("")},
+			input: &github.PullRequest{
+				Labels: []*github.Label{{Name: github.String("v1")}},
+			},
+			expected: "1 check(s) failed:\n* rule \"noted\" failed: add a release note\n    [fail] note(\"releasenote\"): notes were []",
+		},
+		{
+			cmd: &Action{RequireAny: true, RuleFlags: []string{`based=base("^release-.+") || author("alice")`}, NoteRegex: DefaultNoteRegex, GetPullRequestBody: stubPRBody("")},
+			input: &github.PullRequest{
+				User: &github.User{Login: github.String("alice")},
+				Base: &github.PullRequestBranch{Ref: github.String("master")},
+			},
+			expected: "",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		err := tc.cmd.HandlePullRequest("myuser", "myrepo", tc.input)
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package pullvet
+
+import (
+	"log"
+	"regexp"
+	"sort"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+	"github.com/variantdev/go-actions/pkg/freeze"
+)
+
+// facts is what pullvet evaluates requirements and rules against.
+// Anything that requires an extra API call is loaded lazily, and only once.
+type facts struct {
+	action *Action
+
+	owner, repo string
+	pr          *github.PullRequest
+
+	labels     []string
+	labelSet   map[string]struct{}
+	milestone  string
+	body       string
+	noteTitles map[string]struct{}
+	// notes are the bodies of the notes, keyed by title
+	notes      map[string][]string
+	noteSchema *NoteSchema
+
+	reviews       map[string]*github.PullRequestReview
+	approvedUsers map[string]struct{}
+	changedFiles  []string
+	prCommits     []*github.RepositoryCommit
+	issueProblems []string
+
+	teams          map[string]map[string]struct{}
+	codeownersFile *Codeowners
+
+	freeze *freeze.Config
+}
+
+func (c *Action) newFacts(owner, repo string, pullRequest *github.PullRequest) (*facts, error) {
+	f := &facts{
+		action:     c,
+		owner:      owner,
+		repo:       repo,
+		pr:         pullRequest,
+		labelSet:   map[string]struct{}{},
+		milestone:  pullRequest.Milestone.GetTitle(),
+		noteTitles: map[string]struct{}{},
+		notes:      map[string][]string{},
+		teams:      map[string]map[string]struct{}{},
+	}
+
+	for _, l := range pullRequest.Labels {
+		label := l.GetName()
+		f.labelSet[label] = struct{}{}
+		f.labels = append(f.labels, label)
+	}
+
+	if owner != "" {
+		var err error
+		f.body, err = c.GetPullRequestBody(owner, repo, pullRequest.GetNumber())
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		f.body = pullRequest.GetBody()
+	}
+
+	regex, err := regexp.Compile(c.NoteRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	allNoteMatches := regex.FindAllStringSubmatch(normalizeNewlines(f.body), -1)
+	for _, m := range allNoteMatches {
+		log.Printf("match: %v", m)
+		f.noteTitles[m[1]] = struct{}{}
+		if len(m) > 2 {
+			f.notes[m[1]] = append(f.notes[m[1]], m[2])
+		} else {
+			f.notes[m[1]] = append(f.notes[m[1]], "")
+		}
+	}
+
+	log.Printf("note titles: %v", f.noteTitles)
+
+	return f, nil
+}
+
+// latestReviews returns the latest review of each user, keyed by login, as in actions.LatestReviews
+func (f *facts) latestReviews() (map[string]*github.PullRequestReview, error) {
+	if f.reviews != nil {
+		return f.reviews, nil
+	}
+
+	reviews, err := f.action.ListReviews(f.owner, f.repo, f.pr.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	latest := actions.LatestReviews(reviews)
+
+	f.reviews = latest
+
+	return latest, nil
+}
+
+// approvals returns the set of users whose latest review is an approval.
+// Approvals for commits other than the head are ignored when IgnoreStaleApprovals is set.
+func (f *facts) approvals() (map[string]struct{}, error) {
+	if f.approvedUsers != nil {
+		return f.approvedUsers, nil
+	}
+
+	reviews, err := f.latestReviews()
+	if err != nil {
+		return nil, err
+	}
+
+	approvedUsers := map[string]struct{}{}
+	for login, r := range reviews {
+		if r.GetState() != ReviewStateApproved {
+			continue
+		}
+
+		if f.action.IgnoreStaleApprovals && r.GetCommitID() != f.pr.GetHead().GetSHA() {
+			log.Printf("Ignored stale approval by %s for commit %s", login, r.GetCommitID())
+			continue
+		}
+
+		approvedUsers[login] = struct{}{}
+	}
+
+	f.approvedUsers = approvedUsers
+
+	return approvedUsers, nil
+}
+
+// changesRequested returns the set of users whose latest review requests changes
+func (f *facts) changesRequested() (map[string]struct{}, error) {
+	reviews, err := f.latestReviews()
+	if err != nil {
+		return nil, err
+	}
+
+	users := map[string]struct{}{}
+	for login, r := range reviews {
+		if r.GetState() == ReviewStateChangesRequested {
+			users[login] = struct{}{}
+		}
+	}
+
+	return users, nil
+}
+
+// changed returns the paths of the files changed in the pull request
+func (f *facts) changed() ([]string, error) {
+	if f.changedFiles != nil {
+		return f.changedFiles, nil
+	}
+
+	files, err := f.action.ListFiles(f.owner, f.repo, f.pr.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []string{}
+	for _, file := range files {
+		changed = append(changed, file.GetFilename())
+		// A renamed file changes both the previous and the new paths
+		if prev := file.GetPreviousFilename(); prev != "" {
+			changed = append(changed, prev)
+		}
+	}
+
+	f.changedFiles = changed
+
+	return changed, nil
+}
+
+func sortedStrings(items []string) []string {
+	sorted := append([]string{}, items...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	var keys []string
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
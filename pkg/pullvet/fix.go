@@ -0,0 +1,283 @@
+package pullvet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+)
+
+// Fix declares how to fix the pull request when the rule failed, like:
+//
+//	rules:
+//	- name: milestoned
+//	  expr: milestone_match("^v.+")
+//	  fix:
+//	    milestone-match: "^v.+"
+type Fix struct {
+	// AddLabels are labels added to the pull request
+	AddLabels []string `yaml:"add-labels"`
+	// MilestoneMatch is the regexp pattern of the open milestone to assign. The one with the nearest due date wins
+	MilestoneMatch string `yaml:"milestone-match"`
+	// RequestReviewers are either logins or teams in the `org/team` form to request reviews from
+	RequestReviewers []string `yaml:"request-reviewers"`
+}
+
+// mutation is a change made to the pull request in the -fix mode
+type mutation struct {
+	// reason is why the change was needed, like `rule "sized" failed`
+	reason string
+	apply  func() (string, error)
+}
+
+// ruleFix is the fix for the failed rule or requirement
+type ruleFix struct {
+	reason string
+	fix    Fix
+}
+
+// legacyFixes returns the fixes for the requirements given via flags
+func (c *Action) legacyFixes(report *Report) []ruleFix {
+	passed := map[string]bool{}
+	failed := map[string]bool{}
+	for _, r := range report.Results {
+		if r.Passed {
+			passed[r.Name] = true
+		} else {
+			failed[r.Name] = true
+		}
+	}
+
+	var fixes []ruleFix
+
+	// Labels and milestones are fixed only when none of them is present, so that fixes don't conflict with what the author chose
+	if c.FixLabel != "" && failed["label"] && !passed["label"] {
+		fixes = append(fixes, ruleFix{reason: fmt.Sprintf("none of labels %s was present", quoteList(c.Labels)), fix: Fix{AddLabels: []string{c.FixLabel}}})
+	}
+
+	if c.FixMilestone && failed["milestone-match"] && !passed["milestone-match"] {
+		pattern := strings.Join(c.MilestoneMatches, "|")
+		fixes = append(fixes, ruleFix{reason: fmt.Sprintf("milestone did not match %q", pattern), fix: Fix{MilestoneMatch: pattern}})
+	}
+
+	if len(c.FixReviewers) > 0 && (failed["approved-by"] || failed["min-approvals"] || failed["approved-by-team"] || failed["codeowners"]) {
+		fixes = append(fixes, ruleFix{reason: "the pull request needs more approvals", fix: Fix{RequestReviewers: c.FixReviewers}})
+	}
+
+	return fixes
+}
+
+// fix applies the fixes for failures in the report, and posts a comment explaining each change.
+// It returns the number of changes made.
+func (c *Action) fix(owner, repo string, pullRequest *github.PullRequest, report *Report) (int, error) {
+	fixes := append(c.legacyFixes(report), report.fixes...)
+
+	labelSet := map[string]struct{}{}
+	for _, l := range pullRequest.Labels {
+		labelSet[l.GetName()] = struct{}{}
+	}
+
+	var mutations []mutation
+
+	for _, f := range fixes {
+		f := f
+
+		for _, l := range f.fix.AddLabels {
+			if _, ok := labelSet[l]; ok {
+				continue
+			}
+			labelSet[l] = struct{}{}
+
+			label := l
+			mutations = append(mutations, mutation{reason: f.reason, apply: func() (string, error) {
+				if err := c.AddLabels(owner, repo, pullRequest.GetNumber(), []string{label}); err != nil {
+					return "", err
+				}
+				pullRequest.Labels = append(pullRequest.Labels, &github.Label{Name: github.String(label)})
+				return fmt.Sprintf("added the label `%s`", label), nil
+			}})
+		}
+
+		if f.fix.MilestoneMatch != "" {
+			mutations = append(mutations, mutation{reason: f.reason, apply: func() (string, error) {
+				m, err := c.nearestMilestone(owner, repo, f.fix.MilestoneMatch)
+				if err != nil || m == nil {
+					return "", err
+				}
+				if err := c.SetMilestone(owner, repo, pullRequest.GetNumber(), m.GetNumber()); err != nil {
+					return "", err
+				}
+				pullRequest.Milestone = m
+				return fmt.Sprintf("set the milestone to `%s`", m.GetTitle()), nil
+			}})
+		}
+
+		if len(f.fix.RequestReviewers) > 0 {
+			mutations = append(mutations, mutation{reason: f.reason, apply: func() (string, error) {
+				req := reviewersRequest(f.fix.RequestReviewers, pullRequest.GetUser().GetLogin())
+				if len(req.Reviewers) == 0 && len(req.TeamReviewers) == 0 {
+					return "", nil
+				}
+				if err := c.RequestReviewers(owner, repo, pullRequest.GetNumber(), req); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("requested reviews from %s", strings.Join(f.fix.RequestReviewers, ", ")), nil
+			}})
+		}
+	}
+
+	var applied int
+
+	for _, m := range mutations {
+		done, err := m.apply()
+		if err != nil {
+			return applied, err
+		}
+
+		if done == "" {
+			continue
+		}
+
+		applied++
+
+		log.Printf("Fixed: %s as %s", done, m.reason)
+
+		body := fmt.Sprintf("pullvet %s, as %s.", done, m.reason)
+		if err := c.CreateComment(owner, repo, pullRequest.GetNumber(), body); err != nil {
+			return applied, err
+		}
+	}
+
+	return applied, nil
+}
+
+// reviewersRequest splits reviewers into users and teams, excluding the author who can't review their own pull request
+func reviewersRequest(reviewers []string, author string) github.ReviewersRequest {
+	var req github.ReviewersRequest
+	for _, r := range reviewers {
+		r = strings.TrimPrefix(r, "@")
+		if orgSlug := strings.SplitN(r, "/", 2); len(orgSlug) == 2 {
+			req.TeamReviewers = append(req.TeamReviewers, orgSlug[1])
+		} else if !strings.EqualFold(r, author) {
+			req.Reviewers = append(req.Reviewers, r)
+		}
+	}
+	return req
+}
+
+// nearestMilestone returns the open milestone matching the pattern with the nearest due date in the future.
+// Milestones without due dates come after the ones with due dates. It returns nil when none matched
+func (c *Action) nearestMilestone(owner, repo, pattern string) (*github.Milestone, error) {
+	rs, err := compileRegexps([]string{pattern})
+	if err != nil {
+		return nil, err
+	}
+
+	milestones, err := c.ListMilestones(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+
+	var candidates []*github.Milestone
+	for _, m := range milestones {
+		if !matchAny(rs, m.GetTitle()) {
+			continue
+		}
+		if m.DueOn != nil && m.GetDueOn().Before(now) {
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+
+	if len(candidates) == 0 {
+		log.Printf("No open milestone matched %q", pattern)
+		return nil, nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.DueOn == nil || b.DueOn == nil {
+			return b.DueOn == nil && a.DueOn != nil
+		}
+		return a.GetDueOn().Before(b.GetDueOn())
+	})
+
+	return candidates[0], nil
+}
+
+func AddLabels(owner, repo string, num int, labels []string) error {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.Issues.AddLabelsToIssue(context.Background(), owner, repo, num, labels)
+
+	return err
+}
+
+func SetMilestone(owner, repo string, num int, milestone int) error {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.Issues.Edit(context.Background(), owner, repo, num, &github.IssueRequest{Milestone: github.Int(milestone)})
+
+	return err
+}
+
+func ListMilestones(owner, repo string) ([]*github.Milestone, error) {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var milestones []*github.Milestone
+
+	opt := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, res, err := client.Issues.ListMilestones(context.Background(), owner, repo, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		milestones = append(milestones, page...)
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	return milestones, nil
+}
+
+func RequestReviewers(owner, repo string, num int, reviewers github.ReviewersRequest) error {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.PullRequests.RequestReviewers(context.Background(), owner, repo, num, reviewers)
+
+	return err
+}
+
+func CreateComment(owner, repo string, num int, body string) error {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.Issues.CreateComment(context.Background(), owner, repo, num, &github.IssueComment{Body: github.String(body)})
+
+	return err
+}
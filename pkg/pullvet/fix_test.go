@@ -0,0 +1,128 @@
+package pullvet
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestFix(t *testing.T) {
+	due := func(days int) *time.Time {
+		t := time.Now().Add(time.Duration(days) * 24 * time.Hour)
+		return &t
+	}
+
+	milestones := []*github.Milestone{
+		{Number: github.Int(1), Title: github.String("v1.0"), DueOn: due(-10)},
+		{Number: github.Int(2), Title: github.String("v1.2"), DueOn: due(30)},
+		{Number: github.Int(3), Title: github.String("v1.1"), DueOn: due(10)},
+		{Number: github.Int(4), Title: github.String("v2.0")},
+		{Number: github.Int(5), Title: github.String("backlog"), DueOn: due(1)},
+	}
+
+	testcases := []struct {
+		cmd              *Action
+		labels           []string
+		expectedErr      bool
+		expectedComments []string
+		expectedLabels   []string
+		expectedMS       int
+		expectedReviews  []string
+	}{
+		{
+			cmd:              &Action{Labels: []string{"bug", "feature"}, FixLabel: "feature", MilestoneMatches: []string{"^v"}, FixMilestone: true},
+			expectedComments: []string{"pullvet added the label `feature`, as none of labels [\"bug\", \"feature\"] was present.", "pullvet set the milestone to `v1.1`, as milestone did not match \"^v\"."},
+			expectedLabels:   []string{"feature"},
+			expectedMS:       3,
+		},
+		{
+			cmd:    &Action{Labels: []string{"bug", "feature"}, FixLabel: "feature"},
+			labels: []string{"bug"},
+		},
+		{
+			cmd: &Action{
+				Rules: []Rule{{
+					Name:    "sized",
+					Message: "add a size label",
+					Expr:    `label_match("size/.+")`,
+					Fix:     &Fix{AddLabels: []string{"size/unknown"}, RequestReviewers: []string{"alice", "myorg/core"}},
+				}},
+			},
+			expectedComments: []string{"pullvet added the label `size/unknown`, as rule \"sized\" failed: add a size label.", "pullvet requested reviews from alice, myorg/core, as rule \"sized\" failed: add a size label."},
+			expectedLabels:   []string{"size/unknown"},
+			expectedReviews:  []string{"alice", "core"},
+		},
+		{
+			// Requesting reviews doesn't fix the approvals by itself
+			cmd:              &Action{MinApprovals: 1, FixReviewers: []string{"alice"}},
+			expectedErr:      true,
+			expectedComments: []string{"pullvet requested reviews from alice, as the pull request needs more approvals."},
+			expectedReviews:  []string{"alice"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		var comments, labels, reviews []string
+		var milestone int
+
+		tc.cmd.Fix = true
+		tc.cmd.RequireAny = true
+		tc.cmd.NoteRegex = DefaultNoteRegex
+		tc.cmd.GetPullRequestBody = func(owner, repo string, num int) (string, error) {
+			return "", nil
+		}
+		tc.cmd.ListReviews = func(owner, repo string, num int) ([]*github.PullRequestReview, error) {
+			return nil, nil
+		}
+		tc.cmd.ListMilestones = func(owner, repo string) ([]*github.Milestone, error) {
+			return milestones, nil
+		}
+		tc.cmd.AddLabels = func(owner, repo string, num int, ls []string) error {
+			labels = append(labels, ls...)
+			return nil
+		}
+		tc.cmd.SetMilestone = func(owner, repo string, num int, m int) error {
+			milestone = m
+			return nil
+		}
+		tc.cmd.RequestReviewers = func(owner, repo string, num int, req github.ReviewersRequest) error {
+			reviews = append(append(reviews, req.Reviewers...), req.TeamReviewers...)
+			return nil
+		}
+		tc.cmd.CreateComment = func(owner, repo string, num int, body string) error {
+			comments = append(comments, body)
+			return nil
+		}
+
+		input := &github.PullRequest{User: &github.User{Login: github.String("bob")}}
+		for _, l := range tc.labels {
+			input.Labels = append(input.Labels, &github.Label{Name: github.String(l)})
+		}
+
+		err := tc.cmd.HandlePullRequest("myorg", "myrepo", input)
+
+		if tc.expectedErr != (err != nil) {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(comments, tc.expectedComments) {
+			t.Errorf("testcases[%d]: unexpected comments: expected=%q, got=%q", i, tc.expectedComments, comments)
+		}
+
+		if !reflect.DeepEqual(labels, tc.expectedLabels) {
+			t.Errorf("testcases[%d]: unexpected labels: expected=%q, got=%q", i, tc.expectedLabels, labels)
+		}
+
+		if milestone != tc.expectedMS {
+			t.Errorf("testcases[%d]: unexpected milestone: expected=%d, got=%d", i, tc.expectedMS, milestone)
+		}
+
+		if !reflect.DeepEqual(reviews, tc.expectedReviews) {
+			t.Errorf("testcases[%d]: unexpected reviewers: expected=%q, got=%q", i, tc.expectedReviews, reviews)
+		}
+	}
+}
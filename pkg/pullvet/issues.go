@@ -0,0 +1,185 @@
+package pullvet
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// issueRefRegex matches issue references like `Fixes #12`, `closes org/repo#34` and `#56`
+var issueRefRegex = regexp.MustCompile(`(?i)(?:^|[\s(\[,;])(?:(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+)?(?:([\w.-]+)/([\w.-]+))?#(\d+)\b`)
+
+// issueURLRegex matches issue URLs like `https://github.com/org/repo/issues/78`
+var issueURLRegex = regexp.MustCompile(`https://github\.com/([\w.-]+)/([\w.-]+)/issues/(\d+)\b`)
+
+// inlineCodeRegex matches code spans like `#12`
+var inlineCodeRegex = regexp.MustCompile("`+[^`\n]*`+")
+
+// IssueRef is a reference to an issue found in the pull request description
+type IssueRef struct {
+	Owner, Repo string
+	Number      int
+}
+
+func (r IssueRef) String() string {
+	return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+}
+
+// ParseIssueRefs returns the issues referenced in the body, in the order of appearance.
+// References without the repository like `#12` refer to the issues in owner/repo.
+// References in HTML comments, code and quotes are ignored, as they are often left by pull request templates or copied from elsewhere.
+func ParseIssueRefs(body, owner, repo string) []IssueRef {
+	var refs []IssueRef
+
+	index := map[string]int{}
+
+	add := func(ref IssueRef) {
+		key := strings.ToLower(ref.String())
+		if _, ok := index[key]; ok {
+			return
+		}
+		index[key] = len(refs)
+		refs = append(refs, ref)
+	}
+
+	body = prose(body)
+
+	for _, m := range issueRefRegex.FindAllStringSubmatch(body, -1) {
+		num, _ := strconv.Atoi(m[3])
+
+		ref := IssueRef{Owner: owner, Repo: repo, Number: num}
+		if m[1] != "" {
+			ref.Owner, ref.Repo = m[1], m[2]
+		}
+
+		add(ref)
+	}
+
+	for _, m := range issueURLRegex.FindAllStringSubmatch(body, -1) {
+		num, _ := strconv.Atoi(m[3])
+
+		add(IssueRef{Owner: m[1], Repo: m[2], Number: num})
+	}
+
+	return refs
+}
+
+// prose returns the Markdown text without HTML comments, fenced code blocks, code spans and block quotes
+func prose(body string) string {
+	var lines []string
+
+	var fence string
+
+	for _, line := range strings.Split(htmlCommentRegex.ReplaceAllString(normalizeNewlines(body), ""), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			// A fence is closed by a fence of the same character at least as long as the opening one
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+
+		lines = append(lines, inlineCodeRegex.ReplaceAllString(line, " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// linkedIssueProblems describes what is missing for the pull request to have linked issues.
+// Every referenced issue must exist, be open, and have any of LinkedIssueLabels and a milestone matching LinkedIssueMilestoneMatches if given.
+// References to pull requests are ignored.
+func (f *facts) linkedIssueProblems() ([]string, error) {
+	if f.issueProblems != nil {
+		return f.issueProblems, nil
+	}
+
+	milestoneRegexs, err := compileRegexps(f.action.LinkedIssueMilestoneMatches)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := []string{}
+
+	var linked int
+
+	for _, ref := range ParseIssueRefs(f.body, f.owner, f.repo) {
+		issue, err := f.action.GetIssue(ref.Owner, ref.Repo, ref.Number)
+		if err != nil {
+			if e, ok := err.(*github.ErrorResponse); ok && e.Response != nil && e.Response.StatusCode == 404 {
+				problems = append(problems, fmt.Sprintf("linked issue %s does not exist", ref))
+				continue
+			}
+			return nil, fmt.Errorf("getting issue %s: %v", ref, err)
+		}
+
+		if issue.IsPullRequest() {
+			log.Printf("Ignored %s as it is a pull request", ref)
+			continue
+		}
+
+		linked++
+
+		if issue.GetState() != "open" {
+			problems = append(problems, fmt.Sprintf("linked issue %s is %s", ref, issue.GetState()))
+		}
+
+		if labels := f.action.LinkedIssueLabels; len(labels) > 0 {
+			var found bool
+			for _, l := range issue.Labels {
+				if contains(labels, l.GetName()) {
+					found = true
+				}
+			}
+			if !found {
+				problems = append(problems, fmt.Sprintf("linked issue %s misses any of labels %s", ref, quoteList(labels)))
+			}
+		}
+
+		if len(milestoneRegexs) > 0 {
+			milestone := issue.GetMilestone().GetTitle()
+			if !matchAny(milestoneRegexs, milestone) {
+				problems = append(problems, fmt.Sprintf("milestone of linked issue %s did not match any of %s: got %q", ref, quoteList(f.action.LinkedIssueMilestoneMatches), milestone))
+			}
+		}
+	}
+
+	if linked == 0 {
+		problems = append(problems, "missing linked issue: reference an issue like \"Fixes #123\" in the description")
+	}
+
+	f.issueProblems = problems
+
+	return problems, nil
+}
+
+// linkedIssuePredicate holds when the pull request references one or more issues, and every referenced issue is valid
+func linkedIssuePredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		problems, err := f.linkedIssueProblems()
+		if err != nil {
+			return false, "", err
+		}
+		if len(problems) == 0 {
+			return true, "", nil
+		}
+		return false, strings.Join(problems, "; "), nil
+	}, nil
+}
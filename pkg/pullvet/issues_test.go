@@ -0,0 +1,143 @@
+package pullvet
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestParseIssueRefs(t *testing.T) {
+	body := "Fixes #12, closes myorg/other#34 and resolves: #12.\r\nSee #56 and https://github.com/myorg/docs/issues/78\nNot an issue: abc#90 or `#` alone"
+
+	expected := []IssueRef{
+		{Owner: "myuser", Repo: "myrepo", Number: 12},
+		{Owner: "myorg", Repo: "other", Number: 34},
+		{Owner: "myuser", Repo: "myrepo", Number: 56},
+		{Owner: "myorg", Repo: "docs", Number: 78},
+	}
+
+	got := ParseIssueRefs(body, "myuser", "myrepo")
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected refs: expected=%+v, got=%+v", expected, got)
+	}
+}
+
+func TestParseIssueRefsIgnoresNonProse(t *testing.T) {
+	body := strings.Join([]string{
+		"<!-- Reference an issue like: Fixes #1 -->",
+		"<!--",
+		"Closes #2",
+		"-->",
+		"Run `git log --grep #3` to see the history.",
+		"```",
+		"Fixes #4",
+		"````",
+		"~~~~",
+		"Fixes #5",
+		"~~~",
+		"Fixes #6",
+		"~~~~",
+		"> Fixes #7",
+		"Fixes #8",
+	}, "\n")
+
+	expected := []IssueRef{
+		{Owner: "myuser", Repo: "myrepo", Number: 8},
+	}
+
+	got := ParseIssueRefs(body, "myuser", "myrepo")
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected refs: expected=%+v, got=%+v", expected, got)
+	}
+}
+
+func TestLinkedIssue(t *testing.T) {
+	stubPRBody := func(body string) func(owner, repo string, num int) (string, error) {
+		return func(owner, repo string, num int) (string, error) {
+			return body, nil
+		}
+	}
+
+	issues := map[int]*github.Issue{
+		1: {State: github.String("open"), Labels: []github.Label{{Name: github.String("accepted")}}, Milestone: &github.Milestone{Title: github.String("v1")}},
+		2: {State: github.String("closed")},
+		3: {State: github.String("open"), PullRequestLinks: &github.PullRequestLinks{URL: github.String("https://api.github.com/repos/myuser/myrepo/pulls/3")}},
+	}
+
+	stubGetIssue := func(owner, repo string, num int) (*github.Issue, error) {
+		issue, ok := issues[num]
+		if !ok {
+			return nil, &github.ErrorResponse{Response: &http.Response{StatusCode: 404}, Message: "Not Found"}
+		}
+		return issue, nil
+	}
+
+	testcases := []struct {
+		cmd      *Action
+		body     string
+		labels   []string
+		expected string
+	}{
+		{
+			cmd:      &Action{RequireLinkedIssue: true},
+			body:     "Fixes #1",
+			expected: "",
+		},
+		{
+			// Another requirement passing doesn't satisfy -require-linked-issue under -require-any
+			cmd:      &Action{RequireLinkedIssue: true, Labels: []string{"bug"}},
+			body:     "Follow-up of #3",
+			labels:   []string{"bug"},
+			expected: "1 check(s) failed:\n* missing linked issue",
+		},
+		{
+			cmd:      &Action{RequireLinkedIssue: true},
+			body:     "Follow-up of #3",
+			expected: "1 check(s) failed:\n* missing linked issue: reference an issue like \"Fixes #123\" in the description",
+		},
+		{
+			cmd:      &Action{RequireLinkedIssue: true},
+			body:     "Fixes #1, #2 and #4",
+			expected: "2 check(s) failed:\n* linked issue myuser/myrepo#2 is closed\n* linked issue myuser/myrepo#4 does not exist",
+		},
+		{
+			cmd:      &Action{RequireLinkedIssue: true, LinkedIssueLabels: []string{"accepted"}, LinkedIssueMilestoneMatches: []string{"^v2"}},
+			body:     "Fixes #1",
+			expected: "1 check(s) failed:\n* milestone of linked issue myuser/myrepo#1 did not match any of [\"^v2\"]: got \"v1\"",
+		},
+		{
+			cmd:      &Action{RuleFlags: []string{`linked=linked_issue() || label("chore")`}},
+			body:     "Fixes #2",
+			expected: `[fail] linked_issue(): linked issue myuser/myrepo#2 is closed`,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		tc.cmd.RequireAny = true
+		tc.cmd.NoteRegex = DefaultNoteRegex
+		tc.cmd.GetPullRequestBody = stubPRBody(tc.body)
+		tc.cmd.GetIssue = stubGetIssue
+
+		pr := &github.PullRequest{}
+		for _, l := range tc.labels {
+			pr.Labels = append(pr.Labels, &github.Label{Name: github.String(l)})
+		}
+
+		err := tc.cmd.HandlePullRequest("myuser", "myrepo", pr)
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package pullvet
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// matchingLabels returns the labels of the pull request matching the regexp
+func (f *facts) matchingLabels(r *regexp.Regexp) []string {
+	var matched []string
+	for _, l := range f.labels {
+		if r.MatchString(l) {
+			matched = append(matched, l)
+		}
+	}
+	return matched
+}
+
+// oneLabelMatchPredicate holds when exactly one label matches the pattern
+func oneLabelMatchPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	r, err := regexp.Compile(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		matched := f.matchingLabels(r)
+		switch len(matched) {
+		case 1:
+			return true, "", nil
+		case 0:
+			return false, fmt.Sprintf("no label matched: labels were %s", quoteList(f.labels)), nil
+		default:
+			return false, fmt.Sprintf("%d labels matched: %s", len(matched), quoteList(matched)), nil
+		}
+	}, nil
+}
+
+// atMostOneLabelMatchPredicate holds when no label or only one label matches the pattern
+func atMostOneLabelMatchPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	r, err := regexp.Compile(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		matched := f.matchingLabels(r)
+		if len(matched) <= 1 {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("%d labels matched: %s", len(matched), quoteList(matched)), nil
+	}, nil
+}
+
+// noLabelMatchPredicate holds when none of labels matches any of the patterns.
+// Unlike !label_match(...), the failure names the offending labels.
+func noLabelMatchPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	rs, err := compileRegexps(args)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		var matched []string
+		for _, l := range f.labels {
+			if matchAny(rs, l) {
+				matched = append(matched, l)
+			}
+		}
+		if len(matched) == 0 {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("forbidden label(s) %s", quoteList(matched)), nil
+	}, nil
+}
+
+// labelRules returns the rules synthesized from the label set flags
+func (c *Action) labelRules() ([]Rule, error) {
+	var rules []Rule
+
+	for _, p := range c.ExactlyOneLabelMatches {
+		rules = append(rules, Rule{Name: "exactly one label matching " + p, Expr: "one_label_match(" + quoteArg(p) + ")"})
+	}
+
+	for _, p := range c.AtMostOneLabelMatches {
+		rules = append(rules, Rule{Name: "at most one label matching " + p, Expr: "at_most_one_label_match(" + quoteArg(p) + ")"})
+	}
+
+	if len(c.ForbiddenLabelMatches) > 0 {
+		var quoted []string
+		for _, p := range c.ForbiddenLabelMatches {
+			quoted = append(quoted, quoteArg(p))
+		}
+		rules = append(rules, Rule{Name: "forbidden labels", Expr: "no_label_match(" + strings.Join(quoted, ", ") + ")"})
+	}
+
+	for _, s := range c.LabelRequires {
+		kv := strings.SplitN(s, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("unexpected format of %q: expected LABEL=EXPR", s)
+		}
+		rules = append(rules, Rule{
+			Name: "when labeled " + kv[0],
+			When: "label(" + quoteArg(kv[0]) + ")",
+			Expr: kv[1],
+		})
+	}
+
+	return rules, nil
+}
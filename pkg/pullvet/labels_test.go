@@ -0,0 +1,103 @@
+package pullvet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestLabelRules(t *testing.T) {
+	stubPRBody := func(body string) func(owner, repo string, num int) (string, error) {
+		return func(owner, repo string, num int) (string, error) {
+			return body, nil
+		}
+	}
+
+	labels := func(names ...string) []*github.Label {
+		var ls []*github.Label
+		for _, n := range names {
+			ls = append(ls, &github.Label{Name: github.String(n)})
+		}
+		return ls
+	}
+
+	breakingChange := []string{`breaking-change=note("releasenote") && milestone_match("^v[0-9]+\.0\.0$")`}
+
+	testcases := []struct {
+		cmd       *Action
+		labels    []*github.Label
+		milestone string
+		body      string
+		expected  string
+	}{
+		{
+			cmd:    &Action{ExactlyOneLabelMatches: []string{"kind/.+"}},
+			labels: labels("kind/bug"),
+		},
+		{
+			cmd:      &Action{ExactlyOneLabelMatches: []string{"kind/.+"}},
+			labels:   labels("size/S"),
+			expected: "[fail] one_label_match(\"kind/.+\"): no label matched: labels were [\"size/S\"]",
+		},
+		{
+			cmd:      &Action{ExactlyOneLabelMatches: []string{"kind/.+"}},
+			labels:   labels("kind/bug", "kind/feature"),
+			expected: "[fail] one_label_match(\"kind/.+\"): 2 labels matched: [\"kind/bug\", \"kind/feature\"]",
+		},
+		{
+			cmd: &Action{AtMostOneLabelMatches: []string{"size/.+"}},
+		},
+		{
+			cmd:      &Action{AtMostOneLabelMatches: []string{"size/.+"}},
+			labels:   labels("size/S", "size/XL"),
+			expected: "rule \"at most one label matching size/.+\" failed\n    [fail] at_most_one_label_match(\"size/.+\"): 2 labels matched: [\"size/S\", \"size/XL\"]",
+		},
+		{
+			// Forbidden labels fail the pull request even though the requirement given via -label passed
+			cmd:      &Action{Labels: []string{"approved"}, ForbiddenLabelMatches: []string{"do-not-merge/.+"}},
+			labels:   labels("approved", "do-not-merge/hold"),
+			expected: "[fail] no_label_match(\"do-not-merge/.+\"): forbidden label(s) [\"do-not-merge/hold\"]",
+		},
+		{
+			cmd:    &Action{LabelRequires: breakingChange},
+			labels: labels("kind/bug"),
+		},
+		{
+			cmd:       &Action{LabelRequires: breakingChange},
+			labels:    labels("breaking-change"),
+			milestone: "v2.1.0",
+			body:      "**releasenote**:\n```\nRemoved the flag\n```\n",
+			expected:  "rule \"when labeled breaking-change\" failed",
+		},
+		{
+			cmd:       &Action{LabelRequires: breakingChange},
+			labels:    labels("breaking-change"),
+			milestone: "v2.0.0",
+			body:      "**releasenote**:\n```\nRemoved the flag\n```\n",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		tc.cmd.RequireAny = true
+		tc.cmd.NoteRegex = DefaultNoteRegex
+		tc.cmd.GetPullRequestBody = stubPRBody(tc.body)
+
+		pr := &github.PullRequest{Labels: tc.labels}
+		if tc.milestone != "" {
+			pr.Milestone = &github.Milestone{Title: github.String(tc.milestone)}
+		}
+
+		err := tc.cmd.HandlePullRequest("myuser", "myrepo", pr)
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package pullvet
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"gopkg.in/yaml.v2"
+)
+
+// NoneNote is the body of a note that explicitly opts out of the note, like:
+//
+//	releasenote:
+//	```
+//	NONE
+//	```
+const NoneNote = "NONE"
+
+// NoteSchema declares the notes allowed in the pull request description, like:
+//
+//	notes:
+//	- title: releasenote
+//	  required: true
+//	  allow-none: true
+//	  match: ['\w+ \w+']
+//	  not-match: ['(?i)^todo']
+//	  max-length: 500
+//	- title: changelog
+//	exclusive:
+//	- [releasenote, changelog]
+type NoteSchema struct {
+	Notes []NoteSpec `yaml:"notes"`
+	// AllowUnknown allows notes not declared in Notes. Otherwise any undeclared note is an error
+	AllowUnknown bool `yaml:"allow-unknown"`
+	// Exclusive are groups of note titles. Each group allows at most one of the notes
+	Exclusive [][]string `yaml:"exclusive"`
+}
+
+// NoteSpec declares the requirements for the note titled Title
+type NoteSpec struct {
+	Title    string `yaml:"title"`
+	Required bool   `yaml:"required"`
+	// AllowNone accepts the body "NONE" as an explicit opt-out, regardless of Match, NotMatch and MaxLength
+	AllowNone bool `yaml:"allow-none"`
+	// Match are regexp patterns the body must match all of
+	Match []string `yaml:"match"`
+	// NotMatch are regexp patterns the body must match none of
+	NotMatch []string `yaml:"not-match"`
+	// MaxLength is the maximum number of characters in the body. Zero means no limit
+	MaxLength int `yaml:"max-length"`
+
+	match, notMatch []*regexp.Regexp
+}
+
+// ParseNoteSchema parses and validates the note schema
+func ParseNoteSchema(bs []byte) (*NoteSchema, error) {
+	var schema NoteSchema
+
+	if err := yaml.UnmarshalStrict(bs, &schema); err != nil {
+		return nil, err
+	}
+
+	titles := map[string]struct{}{}
+
+	for i := range schema.Notes {
+		n := &schema.Notes[i]
+
+		if n.Title == "" {
+			return nil, fmt.Errorf("notes[%d]: missing title", i)
+		}
+
+		if _, ok := titles[n.Title]; ok {
+			return nil, fmt.Errorf("note %q: duplicate title", n.Title)
+		}
+		titles[n.Title] = struct{}{}
+
+		var err error
+
+		if n.match, err = compileRegexps(n.Match); err != nil {
+			return nil, fmt.Errorf("note %q: match: %v", n.Title, err)
+		}
+
+		if n.notMatch, err = compileRegexps(n.NotMatch); err != nil {
+			return nil, fmt.Errorf("note %q: not-match: %v", n.Title, err)
+		}
+	}
+
+	for _, group := range schema.Exclusive {
+		if len(group) < 2 {
+			return nil, fmt.Errorf("exclusive group %s: needs two or more note titles", quoteList(group))
+		}
+
+		for _, t := range group {
+			if _, ok := titles[t]; !ok && !schema.AllowUnknown {
+				return nil, fmt.Errorf("exclusive group %s: undeclared note %q", quoteList(group), t)
+			}
+		}
+	}
+
+	return &schema, nil
+}
+
+// Validate returns the violations of the notes given as bodies keyed by title, reported per note
+func (s *NoteSchema) Validate(notes map[string][]string) []string {
+	var violations []string
+
+	specs := map[string]*NoteSpec{}
+	for i := range s.Notes {
+		specs[s.Notes[i].Title] = &s.Notes[i]
+	}
+
+	for _, n := range s.Notes {
+		if _, ok := notes[n.Title]; !ok && n.Required {
+			violations = append(violations, fmt.Sprintf("missing note titled %q", n.Title))
+		}
+	}
+
+	for _, title := range sortedNoteTitles(notes) {
+		spec, ok := specs[title]
+		if !ok {
+			if !s.AllowUnknown {
+				violations = append(violations, fmt.Sprintf("note %q: unknown note: expected any of %s", title, quoteList(s.titles())))
+			}
+			continue
+		}
+
+		for _, body := range notes[title] {
+			violations = append(violations, spec.validate(body)...)
+		}
+	}
+
+	for _, group := range s.Exclusive {
+		var present []string
+		for _, t := range group {
+			if _, ok := notes[t]; ok {
+				present = append(present, t)
+			}
+		}
+
+		if len(present) > 1 {
+			violations = append(violations, fmt.Sprintf("notes %s are mutually exclusive: got %s", quoteList(group), quoteList(present)))
+		}
+	}
+
+	return violations
+}
+
+func (s *NoteSchema) titles() []string {
+	var titles []string
+	for _, n := range s.Notes {
+		titles = append(titles, n.Title)
+	}
+	return titles
+}
+
+func (n *NoteSpec) validate(body string) []string {
+	body = strings.TrimSpace(body)
+
+	if n.AllowNone && body == NoneNote {
+		return nil
+	}
+
+	var violations []string
+
+	for _, r := range n.match {
+		if !r.MatchString(body) {
+			violations = append(violations, fmt.Sprintf("note %q: body %s did not match %q", n.Title, abbrev(body), r.String()))
+		}
+	}
+
+	for _, r := range n.notMatch {
+		if r.MatchString(body) {
+			violations = append(violations, fmt.Sprintf("note %q: body %s must not match %q", n.Title, abbrev(body), r.String()))
+		}
+	}
+
+	if l := len([]rune(body)); n.MaxLength > 0 && l > n.MaxLength {
+		violations = append(violations, fmt.Sprintf("note %q: body is %d characters long, expected at most %d", n.Title, l, n.MaxLength))
+	}
+
+	return violations
+}
+
+// abbrev quotes the body, shortening it to keep failures readable
+func abbrev(body string) string {
+	const max = 40
+
+	rs := []rune(body)
+	if len(rs) > max {
+		return fmt.Sprintf("%q...", string(rs[:max]))
+	}
+	return fmt.Sprintf("%q", body)
+}
+
+func sortedNoteTitles(notes map[string][]string) []string {
+	var titles []string
+	for t := range notes {
+		titles = append(titles, t)
+	}
+	return sortedStrings(titles)
+}
+
+// loadNoteSchema reads the note schema from either the base branch or the working tree, according to ConfigSource
+func (c *Action) loadNoteSchema(owner, repo string, pullRequest *github.PullRequest) (*NoteSchema, error) {
+	if c.NoteSchemaFile == "" {
+		return nil, nil
+	}
+
+	bs, err := c.readFile(owner, repo, pullRequest, c.NoteSchemaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := ParseNoteSchema(bs)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", c.NoteSchemaFile, err)
+	}
+
+	return schema, nil
+}
+
+// notesValidPredicate holds when the notes conform to the note schema
+func notesValidPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		if f.noteSchema == nil {
+			return false, "", fmt.Errorf("notes_valid() requires the note schema")
+		}
+		violations := f.noteSchema.Validate(f.notes)
+		if len(violations) == 0 {
+			return true, "", nil
+		}
+		return false, strings.Join(violations, "; "), nil
+	}, nil
+}
@@ -0,0 +1,115 @@
+package pullvet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+const testNoteSchema = `
+notes:
+- title: releasenote
+  required: true
+  allow-none: true
+  match: ['\w+ \w+']
+  not-match: ['(?i)^todo']
+  max-length: 50
+- title: changelog
+- title: migration
+exclusive:
+- [changelog, migration]
+`
+
+func TestNoteSchema(t *testing.T) {
+	schema, err := ParseNoteSchema([]byte(testNoteSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		input    map[string][]string
+		expected []string
+	}{
+		{
+			input: map[string][]string{"releasenote": {"Added the foo flag"}},
+		},
+		{
+			input: map[string][]string{"releasenote": {" NONE\n"}, "changelog": {"foo"}},
+		},
+		{
+			input:    map[string][]string{"changelog": {"foo"}},
+			expected: []string{`missing note titled "releasenote"`},
+		},
+		{
+			input: map[string][]string{"releasenote": {"TODO"}, "relnote": {"foo"}},
+			expected: []string{
+				`note "releasenote": body "TODO" did not match "\\w+ \\w+"`,
+				`note "releasenote": body "TODO" must not match "(?i)^todo"`,
+				`note "relnote": unknown note: expected any of ["releasenote", "changelog", "migration"]`,
+			},
+		},
+		{
+			input: map[string][]string{"releasenote": {strings.Repeat("foo bar ", 10)}, "changelog": {"foo"}, "migration": {"bar"}},
+			expected: []string{
+				`note "releasenote": body is 79 characters long, expected at most 50`,
+				`notes ["changelog", "migration"] are mutually exclusive: got ["changelog", "migration"]`,
+			},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		got := schema.Validate(tc.input)
+
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected violations: expected=%q, got=%q", i, tc.expected, got)
+		}
+	}
+}
+
+func TestParseNoteSchemaErrors(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "notes:\n- required: true\n", expected: "notes[0]: missing title"},
+		{input: "notes:\n- title: a\n- title: a\n", expected: `note "a": duplicate title`},
+		{input: "notes:\n- title: a\n  match: ['(']\n", expected: `note "a": match: error parsing regexp`},
+		{input: "notes:\n- title: a\nexclusive:\n- [a, b]\n", expected: `exclusive group ["a", "b"]: undeclared note "b"`},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		_, err := ParseNoteSchema([]byte(tc.input))
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, tc.expected, err)
+		}
+	}
+}
+
+func TestNoteSchemaFile(t *testing.T) {
+	stubFileContent := func(owner, repo, ref, path string) (string, error) {
+		return testNoteSchema, nil
+	}
+
+	cmd := &Action{
+		RequireAny:     true,
+		NoteSchemaFile: ".github/notes.yaml",
+		NoteRegex:      DefaultNoteRegex,
+		GetPullRequestBody: func(owner, repo string, num int) (string, error) {
+			return "releasenote:\n```\nTODO\n```\n", nil
+		},
+		GetFileContent: stubFileContent,
+	}
+
+	err := cmd.HandlePullRequest("myuser", "myrepo", &github.PullRequest{Base: &github.PullRequestBranch{Ref: github.String("master")}})
+
+	expected := "2 check(s) failed:\n* note \"releasenote\": body \"TODO\" did not match"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("unexpected error: expected=%q, got=%v", expected, err)
+	}
+}
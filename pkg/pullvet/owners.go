@@ -0,0 +1,203 @@
+package pullvet
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// TeamRequirement requires Count or more approvals from members of the team
+type TeamRequirement struct {
+	Org   string
+	Slug  string
+	Count int
+}
+
+func (r TeamRequirement) String() string {
+	return r.Org + "/" + r.Slug
+}
+
+// ParseTeamRequirement parses the requirement given in the `ORG/TEAM[:N]` form. N defaults to 1
+func ParseTeamRequirement(s string) (TeamRequirement, error) {
+	r := TeamRequirement{Count: 1}
+
+	team := s
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		team = s[:i]
+
+		n, err := strconv.Atoi(s[i+1:])
+		if err != nil || n < 1 {
+			return r, fmt.Errorf("unexpected format of team %q: N in ORG/TEAM:N must be a positive integer", s)
+		}
+
+		r.Count = n
+	}
+
+	orgSlug := strings.SplitN(strings.TrimPrefix(team, "@"), "/", 2)
+	if len(orgSlug) != 2 || orgSlug[0] == "" || orgSlug[1] == "" {
+		return r, fmt.Errorf("unexpected format of team %q: expected ORG/TEAM[:N]", s)
+	}
+
+	r.Org, r.Slug = orgSlug[0], orgSlug[1]
+
+	return r, nil
+}
+
+// teamMembers returns the set of logins of the team members
+func (f *facts) teamMembers(org, slug string) (map[string]struct{}, error) {
+	key := org + "/" + slug
+
+	if members, ok := f.teams[key]; ok {
+		return members, nil
+	}
+
+	logins, err := f.action.ListTeamMembers(org, slug)
+	if err != nil {
+		return nil, fmt.Errorf("listing members of team %s: %v", key, err)
+	}
+
+	members := map[string]struct{}{}
+	for _, l := range logins {
+		members[l] = struct{}{}
+	}
+
+	f.teams[key] = members
+
+	return members, nil
+}
+
+// teamApprovals returns the logins of the team members who approved the pull request
+func (f *facts) teamApprovals(org, slug string) ([]string, error) {
+	approved, err := f.approvals()
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := f.teamMembers(org, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	var logins []string
+	for _, u := range sortedKeys(approved) {
+		if _, ok := members[u]; ok {
+			logins = append(logins, u)
+		}
+	}
+
+	return logins, nil
+}
+
+// codeowners reads the CODEOWNERS file from the base branch, so that the author of the pull request can't change the owners in the pull request itself
+func (f *facts) codeowners() (*Codeowners, error) {
+	if f.codeownersFile != nil {
+		return f.codeownersFile, nil
+	}
+
+	base := f.pr.GetBase().GetRef()
+
+	for _, path := range CodeownersPaths {
+		content, err := f.action.GetFileContent(f.owner, f.repo, base, path)
+		if err != nil {
+			log.Printf("Skipped %s on branch %q: %v", path, base, err)
+			continue
+		}
+
+		co, err := ParseCodeowners(content)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s on branch %q: %v", path, base, err)
+		}
+
+		log.Printf("Read %d code owner entries from %s on branch %q", len(co.Entries), path, base)
+
+		f.codeownersFile = co
+
+		return co, nil
+	}
+
+	return nil, fmt.Errorf("no CODEOWNERS file found on branch %q: tried %s", base, strings.Join(CodeownersPaths, ", "))
+}
+
+// isOwner returns true when the user is any of the owners.
+// Owners specified by email addresses are ignored, as reviews don't tell the email address of the reviewer
+func (f *facts) isOwner(login string, owners []string) (bool, error) {
+	for _, o := range owners {
+		if !strings.HasPrefix(o, "@") {
+			continue
+		}
+
+		name := strings.TrimPrefix(o, "@")
+
+		if orgSlug := strings.SplitN(name, "/", 2); len(orgSlug) == 2 {
+			members, err := f.teamMembers(orgSlug[0], orgSlug[1])
+			if err != nil {
+				return false, err
+			}
+
+			if _, ok := members[login]; ok {
+				return true, nil
+			}
+		} else if strings.EqualFold(name, login) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// unapprovedCodeowners describes the changed paths that no code owner approved yet, grouped by CODEOWNERS entry.
+// Paths without owners don't require approvals.
+func (f *facts) unapprovedCodeowners() ([]string, error) {
+	co, err := f.codeowners()
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := f.changed()
+	if err != nil {
+		return nil, err
+	}
+
+	approved, err := f.approvals()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*CodeownersEntry
+	unapproved := map[*CodeownersEntry][]string{}
+
+	for _, path := range changed {
+		e := co.Match(path)
+		if e == nil || len(e.Owners) == 0 {
+			continue
+		}
+
+		var ok bool
+		for _, u := range sortedKeys(approved) {
+			ok, err = f.isOwner(u, e.Owners)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				break
+			}
+		}
+
+		if ok {
+			continue
+		}
+
+		if _, seen := unapproved[e]; !seen {
+			entries = append(entries, e)
+		}
+		unapproved[e] = append(unapproved[e], path)
+	}
+
+	var failures []string
+	for _, e := range entries {
+		failures = append(failures, fmt.Sprintf("missing approval by any of %s for %q: %s", strings.Join(e.Owners, ", "), e.Pattern, strings.Join(unapproved[e], ", ")))
+	}
+
+	return failures, nil
+}
@@ -0,0 +1,202 @@
+package pullvet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+const testCodeowners = `
+# Default owners
+*                   @myorg/core
+*.md                @docs-team-lead
+/deploy/            @myorg/ops
+docs/*              @alice
+apps/               @bob
+/vendor/
+`
+
+func TestCodeownersMatch(t *testing.T) {
+	co, err := ParseCodeowners(testCodeowners)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "main.go", expected: "*"},
+		{input: "pkg/README.md", expected: "*.md"},
+		{input: "deploy/prod/values.yaml", expected: "/deploy/"},
+		{input: "pkg/deploy/deploy.go", expected: "*"},
+		{input: "docs/index.html", expected: "docs/*"},
+		{input: "docs/img/arch.png", expected: "*"},
+		{input: "src/apps/web/main.go", expected: "apps/"},
+		{input: "vendor/github.com/foo/bar.go", expected: "/vendor/"},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		e := co.Match(tc.input)
+		if e == nil {
+			t.Errorf("testcases[%d]: unexpected match: expected=%q, got=nil", i, tc.expected)
+			continue
+		}
+
+		if e.Pattern != tc.expected {
+			t.Errorf("testcases[%d]: unexpected match: expected=%q, got=%q", i, tc.expected, e.Pattern)
+		}
+	}
+}
+
+func TestParseTeamRequirement(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected TeamRequirement
+		err      string
+	}{
+		{input: "myorg/ops", expected: TeamRequirement{Org: "myorg", Slug: "ops", Count: 1}},
+		{input: "@myorg/ops:2", expected: TeamRequirement{Org: "myorg", Slug: "ops", Count: 2}},
+		{input: "ops", err: `unexpected format of team "ops": expected ORG/TEAM[:N]`},
+		{input: "myorg/ops:0", err: `unexpected format of team "myorg/ops:0": N in ORG/TEAM:N must be a positive integer`},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		r, err := ParseTeamRequirement(tc.input)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%v", i, tc.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		} else if r != tc.expected {
+			t.Errorf("testcases[%d]: unexpected requirement: expected=%+v, got=%+v", i, tc.expected, r)
+		}
+	}
+}
+
+func TestOwnerApprovals(t *testing.T) {
+	stubPRBody := func(owner, repo string, num int) (string, error) {
+		return "", nil
+	}
+
+	stubFileContent := func(owner, repo, ref, path string) (string, error) {
+		if ref == "master" && path == ".github/CODEOWNERS" {
+			return testCodeowners, nil
+		}
+		return "", fmt.Errorf("404 Not Found")
+	}
+
+	stubListTeamMembers := func(org, slug string) ([]string, error) {
+		switch org + "/" + slug {
+		case "myorg/core":
+			return []string{"carol", "dave"}, nil
+		case "myorg/ops":
+			return []string{"erin"}, nil
+		}
+		return nil, fmt.Errorf("404 Not Found")
+	}
+
+	stubListFiles := func(paths ...string) func(owner, repo string, num int) ([]*github.CommitFile, error) {
+		return func(owner, repo string, num int) ([]*github.CommitFile, error) {
+			var files []*github.CommitFile
+			for _, p := range paths {
+				files = append(files, &github.CommitFile{Filename: github.String(p)})
+			}
+			return files, nil
+		}
+	}
+
+	stubApprovals := func(logins ...string) func(owner, repo string, num int) ([]*github.PullRequestReview, error) {
+		return func(owner, repo string, num int) ([]*github.PullRequestReview, error) {
+			var reviews []*github.PullRequestReview
+			for _, l := range logins {
+				reviews = append(reviews, &github.PullRequestReview{User: &github.User{Login: github.String(l)}, State: github.String(ReviewStateApproved)})
+			}
+			return reviews, nil
+		}
+	}
+
+	testcases := []struct {
+		cmd      *Action
+		expected string
+	}{
+		{
+			cmd:      &Action{ApprovedByTeams: []string{"myorg/core:2"}, ListReviews: stubApprovals("carol", "dave")},
+			expected: "",
+		},
+		{
+			cmd:      &Action{ApprovedByTeams: []string{"myorg/core:2"}, ListReviews: stubApprovals("carol", "erin")},
+			expected: "1 check(s) failed:\n* not enough approvals from team myorg/core: expected at least 2, got 1",
+		},
+		{
+			cmd: &Action{
+				RequireCodeowners: true,
+				ListFiles:         stubListFiles("main.go", "deploy/prod.yaml", "vendor/foo.go"),
+				ListReviews:       stubApprovals("carol", "erin"),
+			},
+			expected: "",
+		},
+		{
+			cmd: &Action{
+				RequireCodeowners: true,
+				ListFiles:         stubListFiles("main.go", "deploy/prod.yaml", "deploy/stg.yaml", "docs/index.html"),
+				ListReviews:       stubApprovals("carol"),
+			},
+			expected: "2 check(s) failed:\n" +
+				"* missing approval by any of @myorg/ops for \"/deploy/\": deploy/prod.yaml, deploy/stg.yaml\n" +
+				"* missing approval by any of @alice for \"docs/*\": docs/index.html",
+		},
+		{
+			// Another requirement passing doesn't satisfy -require-codeowners under -require-any
+			cmd: &Action{
+				RequireCodeowners: true,
+				MinApprovals:      1,
+				ListFiles:         stubListFiles("deploy/prod.yaml"),
+				ListReviews:       stubApprovals("carol"),
+			},
+			expected: "1 check(s) failed:\n* missing approval by any of @myorg/ops for \"/deploy/\": deploy/prod.yaml",
+		},
+		{
+			cmd: &Action{
+				RuleFlags:   []string{`owned=codeowners_approved() || approved_by_team("myorg/ops")`},
+				ListFiles:   stubListFiles("deploy/prod.yaml"),
+				ListReviews: stubApprovals("erin"),
+			},
+			expected: "",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		tc.cmd.RequireAny = true
+		tc.cmd.NoteRegex = DefaultNoteRegex
+		tc.cmd.GetPullRequestBody = stubPRBody
+		tc.cmd.GetFileContent = stubFileContent
+		tc.cmd.ListTeamMembers = stubListTeamMembers
+
+		input := &github.PullRequest{
+			Base: &github.PullRequestBranch{Ref: github.String("master")},
+		}
+
+		err := tc.cmd.HandlePullRequest("myuser", "myrepo", input)
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package pullvet
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/variantdev/go-actions/pkg/glob"
+)
+
+// predicateFunc returns whether the predicate holds for the pull request, along with the detail of the actual value
+type predicateFunc func(f *facts) (bool, string, error)
+
+// predicates are the functions available in rule expressions, keyed by name.
+// Each function validates the arguments and returns the predicate.
+// Predicates accepting multiple arguments hold when any of the arguments matches.
+var predicates = map[string]func(args []string) (predicateFunc, error){
+	"label":                   labelPredicate,
+	"label_match":             labelMatchPredicate,
+	"one_label_match":         oneLabelMatchPredicate,
+	"at_most_one_label_match": atMostOneLabelMatchPredicate,
+	"no_label_match":          noLabelMatchPredicate,
+	"milestone":               milestonePredicate,
+	"milestone_match":         milestoneMatchPredicate,
+	"any_milestone":           anyMilestonePredicate,
+	"note":                    notePredicate,
+	"approved_by":             approvedByPredicate,
+	"min_approvals":           minApprovalsPredicate,
+	"changes_requested":       changesRequestedPredicate,
+	"approved_by_team":        approvedByTeamPredicate,
+	"codeowners_approved":     codeownersApprovedPredicate,
+	"author":                  authorPredicate,
+	"base":                    basePredicate,
+	"head":                    headPredicate,
+	"draft":                   draftPredicate,
+	"author_association":      authorAssociationPredicate,
+	"bot":                     botPredicate,
+	"changed":                 changedPredicate,
+	"title_match":             titleMatchPredicate,
+	"conventional_title":      conventionalTitlePredicate,
+	"conventional_commits":    conventionalCommitsPredicate,
+	"max_subject_length":      maxSubjectLengthPredicate,
+	"commits_match":           commitsMatchPredicate,
+	"signed_off":              signedOffPredicate,
+	"linked_issue":            linkedIssuePredicate,
+	"notes_valid":             notesValidPredicate,
+	"checked":                 checkedPredicate,
+	"all_checked_in_section":  allCheckedInSectionPredicate,
+	"changed_only":            changedOnlyPredicate,
+	"min_open":                minOpenPredicate,
+	"min_since_push":          minSincePushPredicate,
+	"not_frozen":              notFrozenPredicate,
+}
+
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	var rs []*regexp.Regexp
+	for _, p := range patterns {
+		r, err := glob.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+func requireArgs(args []string, min, max int) error {
+	if len(args) < min {
+		return fmt.Errorf("expected at least %d argument(s), got %d", min, len(args))
+	}
+	if max >= 0 && len(args) > max {
+		return fmt.Errorf("expected at most %d argument(s), got %d", max, len(args))
+	}
+	return nil
+}
+
+func compileRegexps(patterns []string) ([]*regexp.Regexp, error) {
+	var rs []*regexp.Regexp
+	for _, p := range patterns {
+		r, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+func matchAny(rs []*regexp.Regexp, s string) bool {
+	for _, r := range rs {
+		if r.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(items []string, s string) bool {
+	for _, i := range items {
+		if i == s {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteList(items []string) string {
+	var quoted []string
+	for _, i := range items {
+		quoted = append(quoted, strconv.Quote(i))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func labelPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		for _, l := range f.labels {
+			if contains(args, l) {
+				return true, "", nil
+			}
+		}
+		return false, fmt.Sprintf("labels were %s", quoteList(f.labels)), nil
+	}, nil
+}
+
+func labelMatchPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	rs, err := compileRegexps(args)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		for _, l := range f.labels {
+			if matchAny(rs, l) {
+				return true, "", nil
+			}
+		}
+		return false, fmt.Sprintf("labels were %s", quoteList(f.labels)), nil
+	}, nil
+}
+
+func milestonePredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		if contains(args, f.milestone) {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("milestone was %q", f.milestone), nil
+	}, nil
+}
+
+func milestoneMatchPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	rs, err := compileRegexps(args)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		if matchAny(rs, f.milestone) {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("milestone was %q", f.milestone), nil
+	}, nil
+}
+
+func anyMilestonePredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		if f.milestone != "" {
+			return true, "", nil
+		}
+		return false, "missing milestone", nil
+	}, nil
+}
+
+func notePredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		for _, t := range args {
+			if _, ok := f.noteTitles[t]; ok {
+				return true, "", nil
+			}
+		}
+		var titles []string
+		for t := range f.noteTitles {
+			titles = append(titles, t)
+		}
+		return false, fmt.Sprintf("notes were %s", quoteList(sortedStrings(titles))), nil
+	}, nil
+}
+
+func approvedByPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		approved, err := f.approvals()
+		if err != nil {
+			return false, "", err
+		}
+		for _, u := range args {
+			if _, ok := approved[u]; ok {
+				return true, "", nil
+			}
+		}
+		return false, fmt.Sprintf("approved by %s", quoteList(sortedKeys(approved))), nil
+	}, nil
+}
+
+func minApprovalsPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		approved, err := f.approvals()
+		if err != nil {
+			return false, "", err
+		}
+		if len(approved) >= n {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("%d approval(s)", len(approved)), nil
+	}, nil
+}
+
+// changesRequestedPredicate holds when any user's latest review requests changes
+func changesRequestedPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		users, err := f.changesRequested()
+		if err != nil {
+			return false, "", err
+		}
+		if len(users) > 0 {
+			return true, fmt.Sprintf("changes requested by %s", quoteList(sortedKeys(users))), nil
+		}
+		return false, "no changes requested", nil
+	}, nil
+}
+
+// approvedByTeamPredicate holds when N or more members of the team approved the pull request. N defaults to 1
+func approvedByTeamPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+	s := args[0]
+	if len(args) == 2 {
+		s += ":" + args[1]
+	}
+	req, err := ParseTeamRequirement(s)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		logins, err := f.teamApprovals(req.Org, req.Slug)
+		if err != nil {
+			return false, "", err
+		}
+		if len(logins) >= req.Count {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("approved by %s from the team", quoteList(logins)), nil
+	}, nil
+}
+
+// codeownersApprovedPredicate holds when an owner of every changed path approved the pull request
+func codeownersApprovedPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		unapproved, err := f.unapprovedCodeowners()
+		if err != nil {
+			return false, "", err
+		}
+		if len(unapproved) == 0 {
+			return true, "", nil
+		}
+		return false, strings.Join(unapproved, "; "), nil
+	}, nil
+}
+
+func authorPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		author := f.pr.GetUser().GetLogin()
+		if contains(args, author) {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("author was %q", author), nil
+	}, nil
+}
+
+func basePredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	rs, err := compileRegexps(args)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		base := f.pr.GetBase().GetRef()
+		if matchAny(rs, base) {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("base branch was %q", base), nil
+	}, nil
+}
+
+func headPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	rs, err := compileRegexps(args)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		head := f.pr.GetHead().GetRef()
+		if matchAny(rs, head) {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("head branch was %q", head), nil
+	}, nil
+}
+
+// changedPredicate holds when any of the changed files matches any of the glob patterns
+func changedPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	rs, err := compileGlobs(args)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		changed, err := f.changed()
+		if err != nil {
+			return false, "", err
+		}
+		for _, path := range changed {
+			if matchAny(rs, path) {
+				return true, "", nil
+			}
+		}
+		return false, "no matching file changed", nil
+	}, nil
+}
+
+// changedOnlyPredicate holds when every changed file matches any of the glob patterns
+func changedOnlyPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	rs, err := compileGlobs(args)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		changed, err := f.changed()
+		if err != nil {
+			return false, "", err
+		}
+		var unmatched []string
+		for _, path := range changed {
+			if !matchAny(rs, path) {
+				unmatched = append(unmatched, path)
+			}
+		}
+		if len(unmatched) == 0 {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("other files changed: %s", quoteList(unmatched)), nil
+	}, nil
+}
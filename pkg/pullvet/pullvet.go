@@ -8,7 +8,9 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v28/github"
 	"github.com/variantdev/go-actions"
@@ -16,6 +18,12 @@ import (
 
 const DefaultNoteRegex = "[\\*]*([^\\*\r\n:]+)[\\*]*:\\s+```[^\n]*\n((?s).*?)\n```"
 
+// States of pull request reviews that count as the latest review of the reviewer
+const (
+	ReviewStateApproved         = "APPROVED"
+	ReviewStateChangesRequested = "CHANGES_REQUESTED"
+)
+
 var newlineRegex = regexp.MustCompile(`\r\n|\r|\n`)
 
 type Action struct {
@@ -36,7 +44,140 @@ type Action struct {
 	MinApprovals       int
 	RequireApprovalsBy actions.StringSlice
 
+	// ApprovedByTeams are teams given in the `ORG/TEAM[:N]` form, that N or more members of each team must approve
+	ApprovedByTeams actions.StringSlice
+	// RequireCodeowners requires an approval from an owner of every changed path, according to the CODEOWNERS file in the base branch
+	RequireCodeowners bool
+
+	// IgnoreStaleApprovals ignores approvals given to commits other than the head of the pull request
+	IgnoreStaleApprovals bool
+
+	// Rules are evaluated in addition to the requirements above, and every rule must hold regardless of RequireAny and RequireAll
+	Rules []Rule
+	// RuleFlags are rules given in the `NAME=EXPR` form
+	RuleFlags actions.StringSlice
+	// RuleMessageFlags are messages for RuleFlags given in the `NAME=MESSAGE` form
+	RuleMessageFlags actions.StringSlice
+
+	// RequireLinkedIssue requires the pull request description to reference one or more open issues
+	RequireLinkedIssue bool
+	// LinkedIssueLabels are labels every linked issue must have any of
+	LinkedIssueLabels actions.StringSlice
+	// LinkedIssueMilestoneMatches are regexp patterns the milestone of every linked issue must match any of
+	LinkedIssueMilestoneMatches actions.StringSlice
+
+	// TitleMatches are regexp patterns the title of the pull request must match any of
+	TitleMatches actions.StringSlice
+	// ConventionalTitle requires the title of the pull request to follow Conventional Commits
+	ConventionalTitle bool
+	// ConventionalCommits requires every commit message to follow Conventional Commits
+	ConventionalCommits bool
+	// ConventionalTypes limits the types allowed in Conventional Commits. Any type is allowed when empty
+	ConventionalTypes actions.StringSlice
+	// MaxSubjectLength is the maximum length of the subject of every commit message
+	MaxSubjectLength int
+	// CommitMatches are regexp patterns every commit message must match any of
+	CommitMatches actions.StringSlice
+	// RequireIssueKey is the regexp pattern of the issue key every commit message must contain, like `[A-Z]+-[0-9]+`
+	RequireIssueKey string
+	// RequireSignoff requires every commit message to have the Signed-off-by trailer of the commit author
+	RequireSignoff bool
+
+	// ExactlyOneLabelMatches are regexp patterns exactly one label of the pull request must match each of
+	ExactlyOneLabelMatches actions.StringSlice
+	// AtMostOneLabelMatches are regexp patterns no more than one label of the pull request can match each of
+	AtMostOneLabelMatches actions.StringSlice
+	// ForbiddenLabelMatches are regexp patterns of labels that fail the pull request regardless of RequireAny and RequireAll
+	ForbiddenLabelMatches actions.StringSlice
+	// LabelRequires are rules given in the `LABEL=EXPR` form, that must hold only when the pull request has the label
+	LabelRequires actions.StringSlice
+
+	// RequireChecked are texts of task list items in the pull request description that must be checked
+	RequireChecked actions.StringSlice
+	// RequireAllCheckedInSections are titles of headings in the pull request description. The section must exist and every task list item under it must be checked
+	RequireAllCheckedInSections actions.StringSlice
+
+	// SkipIf are expressions that skip evaluating the pull request when any of them holds
+	SkipIf actions.StringSlice
+	// SkipAuthors are logins of authors whose pull requests are not evaluated, like dependabot[bot]
+	SkipAuthors actions.StringSlice
+	// SkipBots skips evaluating pull requests opened by bots
+	SkipBots bool
+
+	// MinOpen is the minimum duration the pull request must have been open for
+	MinOpen time.Duration
+	// MinOpenSincePush measures MinOpen from the last push instead of the creation of the pull request
+	MinOpenSincePush bool
+	// FreezeFile is the path to the file declaring freeze windows, read from the same source as ConfigFile
+	FreezeFile string
+
+	// Now returns the current time
+	Now func() time.Time
+
+	// NoteSchemaFile is the path to the note schema file, read from the same source as ConfigFile
+	NoteSchemaFile string
+
+	// Fix fixes the pull request when requirements or rules with fixes failed, instead of just failing
+	Fix bool
+	// FixLabel is added when none of Labels is present
+	FixLabel string
+	// FixMilestone assigns the open milestone matching MilestoneMatches with the nearest due date
+	FixMilestone bool
+	// FixReviewers are requested reviews from when approval requirements failed
+	FixReviewers actions.StringSlice
+
+	// Output is the format of the report, either "text", "json" or "markdown"
+	Output string
+	// CheckRunName is the name of the check run to publish the report as. No check run is published when empty
+	CheckRunName string
+
+	// StatusContext is the context of the commit status to set for the head of the pull request. No status is set when empty
+	StatusContext string
+
+	// AllOpen evaluates every open pull request in Repos instead of the pull request from the event
+	AllOpen bool
+	// Base limits the pull requests evaluated with AllOpen to the ones whose base branch is Base
+	Base string
+	// Repos are repositories in the `owner/repo` form to evaluate with AllOpen. Defaults to the repository of the workflow
+	Repos actions.StringSlice
+
+	// ConfigFile is the path to the config file declaring rule sets
+	ConfigFile string
+	// ConfigSource is either "base" or "worktree"
+	ConfigSource string
+
 	GetPullRequestBody func(string, string, int) (string, error)
+	// GetFileContent returns the content of the file at the ref
+	GetFileContent func(owner, repo, ref, path string) (string, error)
+	// ListFiles returns the files changed in the pull request
+	ListFiles func(owner, repo string, num int) ([]*github.CommitFile, error)
+	// ListTeamMembers returns the logins of the team members
+	ListTeamMembers func(org, slug string) ([]string, error)
+	// GetIssue returns the issue or the pull request
+	GetIssue func(owner, repo string, num int) (*github.Issue, error)
+	// AddLabels, SetMilestone, ListMilestones, RequestReviewers and CreateComment are used to fix the pull request
+	AddLabels        func(owner, repo string, num int, labels []string) error
+	SetMilestone     func(owner, repo string, num int, milestone int) error
+	ListMilestones   func(owner, repo string) ([]*github.Milestone, error)
+	RequestReviewers func(owner, repo string, num int, reviewers github.ReviewersRequest) error
+	CreateComment    func(owner, repo string, num int, body string) error
+
+	// ListPullRequests returns the open pull requests whose base branch is base, or every open pull request if base is empty
+	ListPullRequests func(owner, repo, base string) ([]*github.PullRequest, error)
+	// CreateStatus creates the commit status
+	CreateStatus func(owner, repo, ref string, status *github.RepoStatus) error
+
+	// CreateCheckRun creates the check run
+	CreateCheckRun func(owner, repo string, opt github.CreateCheckRunOptions) error
+	// ListCommits returns the commits in the pull request
+	ListCommits func(owner, repo string, num int) ([]*github.RepositoryCommit, error)
+	// GetPushedAt returns when the head of the pull request was pushed to GitHub
+	GetPushedAt func(owner, repo string, pr *github.PullRequest) (time.Time, error)
+	// ListReviews returns the reviews of the pull request in the chronological order
+	ListReviews func(owner, repo string, num int) ([]*github.PullRequestReview, error)
+
+	// WhenChangedFlags are rules given in the `GLOB=EXPR` form, that must hold only when any file matching GLOB is changed
+	WhenChangedFlags actions.StringSlice
 }
 
 func normalizeNewlines(str string) string {
@@ -46,10 +187,30 @@ func normalizeNewlines(str string) string {
 func New() *Action {
 	return &Action{
 		GetPullRequestBody: GetPullRequestBody,
+		GetFileContent:     GetFileContent,
+		ListFiles:          ListFiles,
+		ListReviews:        ListReviews,
+		ListTeamMembers:    ListTeamMembers,
+		ListCommits:        ListCommits,
+		GetPushedAt:        GetPushedAt,
+		GetIssue:           GetIssue,
+		CreateCheckRun:     CreateCheckRun,
+		AddLabels:          AddLabels,
+		SetMilestone:       SetMilestone,
+		ListMilestones:     ListMilestones,
+		RequestReviewers:   RequestReviewers,
+		CreateComment:      CreateComment,
+		ListPullRequests:   ListPullRequests,
+		CreateStatus:       CreateStatus,
+		Now:                time.Now,
 	}
 }
 
 func (c *Action) Run() error {
+	if c.AllOpen {
+		return c.Audit()
+	}
+
 	pr, owner, repo, err := actions.PullRequest()
 	if err != nil {
 		return err
@@ -57,16 +218,112 @@ func (c *Action) Run() error {
 	return c.HandlePullRequest(owner, repo, pr)
 }
 
+// numRequirements returns the number of requirements given via flags, that are combined with RequireAny or RequireAll
+func (c *Action) numRequirements() int {
+	n := len(c.Labels) + len(c.LabelMatches) + len(c.MilestoneMatches) + len(c.NoteTitles)
+	if c.Milestone != "" {
+		n++
+	}
+	if c.AnyMilestone {
+		n++
+	}
+	if len(c.RequireApprovalsBy) > 0 {
+		n++
+	}
+	if c.MinApprovals > 0 {
+		n++
+	}
+	n += len(c.ApprovedByTeams)
+	return n
+}
+
 func (c *Action) HandlePullRequest(owner, repo string, pullRequest *github.PullRequest) error {
-	var labels []string
-	labelSet := map[string]struct{}{}
+	report, err := c.vet(owner, repo, pullRequest)
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeReport(os.Stdout, report); err != nil {
+		return err
+	}
+
+	if err := c.publish(owner, repo, pullRequest, report); err != nil {
+		return err
+	}
 
-	for _, l := range pullRequest.Labels {
-		label := l.GetName()
-		labelSet[label] = struct{}{}
-		labels = append(labels, label)
+	if !report.Passed {
+		return report.Err()
+	}
+
+	return nil
+}
+
+// vet evaluates the pull request, fixing and re-evaluating it in the -fix mode
+func (c *Action) vet(owner, repo string, pullRequest *github.PullRequest) (*Report, error) {
+	report, err := c.Evaluate(owner, repo, pullRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Fix && !report.Passed {
+		applied, err := c.fix(owner, repo, pullRequest, report)
+		if err != nil {
+			return nil, err
+		}
+
+		if applied > 0 {
+			log.Printf("Re-evaluating the pull request after %d fix(es)", applied)
+
+			report, err = c.Evaluate(owner, repo, pullRequest)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// Evaluate evaluates every requirement and rule against the pull request, without failing on unmet requirements
+func (c *Action) Evaluate(owner, repo string, pullRequest *github.PullRequest) (*Report, error) {
+	rules, err := c.rules()
+	if err != nil {
+		return nil, err
+	}
+
+	configRules, err := c.configRules(owner, repo, pullRequest)
+	if err != nil {
+		return nil, err
 	}
 
+	rules = append(rules, configRules...)
+
+	f, err := c.newFacts(owner, repo, pullRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	skip, err := c.skipReason(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if skip != "" {
+		log.Printf("Skipped evaluating the pull request: %s", skip)
+
+		return &Report{Passed: true, Skipped: skip}, nil
+	}
+
+	f.noteSchema, err = c.loadNoteSchema(owner, repo, pullRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+
+	labels := f.labels
+	labelSet := f.labelSet
+
 	any := false
 	all := true
 
@@ -74,7 +331,9 @@ func (c *Action) HandlePullRequest(owner, repo string, pullRequest *github.PullR
 	var failures []string
 
 	for _, requiredLabel := range c.Labels {
-		if _, ok := labelSet[requiredLabel]; ok {
+		_, ok := labelSet[requiredLabel]
+		report.add("label", ok, requiredLabel, quoteList(labels))
+		if ok {
 			any = true
 			passed += 1
 		} else {
@@ -97,6 +356,7 @@ func (c *Action) HandlePullRequest(owner, repo string, pullRequest *github.PullR
 			}
 		}
 
+		report.add("label-match", matched, r.String(), quoteList(labels))
 		if matched {
 			any = true
 			passed += 1
@@ -106,9 +366,10 @@ func (c *Action) HandlePullRequest(owner, repo string, pullRequest *github.PullR
 		}
 	}
 
-	milestone := pullRequest.Milestone.GetTitle()
+	milestone := f.milestone
 
 	if c.Milestone != "" {
+		report.add("milestone", milestone == c.Milestone, c.Milestone, milestone)
 		if milestone == c.Milestone {
 			any = true
 			passed += 1
@@ -125,6 +386,7 @@ func (c *Action) HandlePullRequest(owner, repo string, pullRequest *github.PullR
 
 	for _, r := range milestoneRegexs {
 		matched := r.MatchString(milestone)
+		report.add("milestone-match", matched, r.String(), milestone)
 		if matched {
 			any = true
 			passed += 1
@@ -134,44 +396,91 @@ func (c *Action) HandlePullRequest(owner, repo string, pullRequest *github.PullR
 		}
 	}
 
-	if len(c.RequireApprovalsBy) > 0 || c.MinApprovals > 0 {
-		client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
-		if err != nil {
-			return err
-		}
-		reviews, res, err := client.PullRequests.ListReviews(context.Background(), owner, repo, pullRequest.GetNumber(), &github.ListOptions{})
-		if err != nil && res.StatusCode != 404 {
-			return err
-		}
+	// Outstanding change requests, unapproved code owners and problems of linked issues fail the pull request regardless of RequireAny and RequireAll
+	var blockers []string
 
-		approvedUsers := map[string]struct{}{}
-		for _, r := range reviews {
-			approvedUsers[r.User.GetLogin()] = struct{}{}
+	if len(c.RequireApprovalsBy) > 0 || c.MinApprovals > 0 || len(c.ApprovedByTeams) > 0 || c.RequireCodeowners {
+		approvedUsers, err := f.approvals()
+		if err != nil {
+			return nil, err
 		}
 
 		if len(c.RequireApprovalsBy) > 0 {
 			allApproved := true
 			for _, u := range c.RequireApprovalsBy {
 				_, ok := approvedUsers[u]
-				allApproved = allApproved && ok
+				report.add("approved-by", ok, u, quoteList(sortedKeys(approvedUsers)))
+				if !ok {
+					allApproved = false
+					failures = append(failures, fmt.Sprintf("missing approval by %s", u))
+				}
 			}
 			if allApproved {
 				any = true
+				passed += 1
 			} else {
 				all = false
 			}
 		}
 
 		if c.MinApprovals > 0 {
-			if len(approvedUsers) > c.MinApprovals {
+			report.add("min-approvals", len(approvedUsers) >= c.MinApprovals, fmt.Sprintf(">= %d", c.MinApprovals), strconv.Itoa(len(approvedUsers)))
+			if len(approvedUsers) >= c.MinApprovals {
 				any = true
+				passed += 1
 			} else {
 				all = false
+				failures = append(failures, fmt.Sprintf("not enough approvals: expected at least %d, got %d", c.MinApprovals, len(approvedUsers)))
 			}
 		}
+
+		for _, t := range c.ApprovedByTeams {
+			req, err := ParseTeamRequirement(t)
+			if err != nil {
+				return nil, err
+			}
+
+			logins, err := f.teamApprovals(req.Org, req.Slug)
+			if err != nil {
+				return nil, err
+			}
+
+			report.add("approved-by-team", len(logins) >= req.Count, fmt.Sprintf("%s >= %d", req, req.Count), quoteList(logins))
+			if len(logins) >= req.Count {
+				any = true
+				passed += 1
+			} else {
+				all = false
+				failures = append(failures, fmt.Sprintf("not enough approvals from team %s: expected at least %d, got %d", req, req.Count, len(logins)))
+			}
+		}
+
+		if c.RequireCodeowners {
+			unapproved, err := f.unapprovedCodeowners()
+			if err != nil {
+				return nil, err
+			}
+
+			report.add("codeowners", len(unapproved) == 0, "approval by an owner of every changed path", strings.Join(unapproved, "; "))
+			if len(unapproved) == 0 {
+				passed += 1
+			}
+			blockers = append(blockers, unapproved...)
+		}
+
+		changesRequested, err := f.changesRequested()
+		if err != nil {
+			return nil, err
+		}
+
+		report.add("changes-requested", len(changesRequested) == 0, "none", quoteList(sortedKeys(changesRequested)))
+		for _, u := range sortedKeys(changesRequested) {
+			blockers = append(blockers, fmt.Sprintf("changes requested by %s", u))
+		}
 	}
 
 	if c.AnyMilestone {
+		report.add("any-milestone", milestone != "", "any", milestone)
 		if milestone != "" {
 			any = true
 			passed += 1
@@ -181,51 +490,90 @@ func (c *Action) HandlePullRequest(owner, repo string, pullRequest *github.PullR
 		}
 	}
 
-	noteTitles := map[string]struct{}{}
+	noteTitles := f.noteTitles
 
-	var body string
+	for _, requiredNoteTitle := range c.NoteTitles {
+		_, ok := noteTitles[requiredNoteTitle]
+		report.add("note", ok, requiredNoteTitle, quoteList(sortedKeys(noteTitles)))
+		if ok {
+			any = true
+			passed += 1
+		} else {
+			all = false
+			failures = append(failures, fmt.Sprintf("missing note titled %q", requiredNoteTitle))
+		}
+	}
 
-	if owner != "" {
-		var err error
-		body, err = c.GetPullRequestBody(owner, repo, pullRequest.GetNumber())
+	if c.RequireLinkedIssue {
+		problems, err := f.linkedIssueProblems()
 		if err != nil {
-			return err
+			return nil, err
 		}
-	} else {
-		body = pullRequest.GetBody()
+
+		report.add("linked-issue", len(problems) == 0, "one or more open linked issues", strings.Join(problems, "; "))
+		if len(problems) == 0 {
+			passed += 1
+		}
+		blockers = append(blockers, problems...)
 	}
 
-	regex := regexp.MustCompile(c.NoteRegex)
+	var requirementsFailed bool
 
-	allNoteMatches := regex.FindAllStringSubmatch(normalizeNewlines(body), -1)
-	for _, m := range allNoteMatches {
-		log.Printf("match: %v", m)
-		noteTitles[m[1]] = struct{}{}
+	// Requirements are not checked when only rules, the note schema or requirements that must hold regardless of RequireAny and RequireAll are given.
+	// Otherwise RequireAny would fail as no requirement passed
+	if c.numRequirements() > 0 || (len(rules) == 0 && f.noteSchema == nil && !c.RequireLinkedIssue && !c.RequireCodeowners) {
+		requirementsFailed = (c.RequireAny && !any) || c.RequireAll && !all
 	}
 
-	log.Printf("note titles: %v", noteTitles)
+	if !requirementsFailed {
+		failures = nil
+	}
 
-	for _, requiredNoteTitle := range c.NoteTitles {
-		if _, ok := noteTitles[requiredNoteTitle]; ok {
-			any = true
+	failures = append(failures, blockers...)
+
+	// The note schema must hold regardless of RequireAny and RequireAll
+	if f.noteSchema != nil {
+		violations := f.noteSchema.Validate(f.notes)
+		report.add("note-schema", len(violations) == 0, c.NoteSchemaFile, strings.Join(violations, "; "))
+		if len(violations) == 0 {
 			passed += 1
-		} else {
-			all = false
-			failures = append(failures, fmt.Sprintf("missing note titled %q", requiredNoteTitle))
 		}
+		failures = append(failures, violations...)
 	}
 
-	if (c.RequireAny && !any) || c.RequireAll && !all {
-		e := fmt.Errorf("%d check(s) failed:\n%s\n", len(failures), formatFailures(failures))
+	for _, r := range rules {
+		applies, err := r.applies(f)
+		if err != nil {
+			return nil, err
+		}
 
-		fmt.Fprintf(os.Stdout, "%s\n", e.Error())
+		if !applies {
+			log.Printf("Skipped rule %q as its condition did not hold", r.Name)
+			report.Results = append(report.Results, Result{Name: r.Name, Passed: true, Skipped: true, Expected: r.Expr})
+			continue
+		}
 
-		return e
+		ev, err := r.evaluate(f)
+		if err != nil {
+			return nil, err
+		}
+
+		report.add(r.Name, ev.passed, r.Expr, strings.Join(ev.failureDetails(), "; "))
+		if ev.passed {
+			passed += 1
+		} else {
+			if r.Fix != nil {
+				report.fixes = append(report.fixes, ruleFix{reason: r.failureReason(), fix: *r.Fix})
+			}
+			failures = append(failures, formatRuleFailure(r, ev))
+		}
 	}
 
-	fmt.Fprintf(os.Stdout, "%d check(s) passed\n", passed)
+	report.Passed = !requirementsFailed && len(failures) == 0
+	report.Failures = failures
+	report.numPassed = passed
 
-	return nil
+	return report, nil
 }
 
 func GetPullRequestBody(owner, repo string, prNumber int) (string, error) {
@@ -250,6 +598,98 @@ func GetPullRequestBody(owner, repo string, prNumber int) (string, error) {
 	return pr.GetBody(), nil
 }
 
+func GetFileContent(owner, repo, ref, path string) (string, error) {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return "", err
+	}
+
+	file, _, _, err := client.Repositories.GetContents(context.Background(), owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		return "", err
+	}
+
+	if file == nil {
+		return "", fmt.Errorf("%s is not a file", path)
+	}
+
+	return file.GetContent()
+}
+
+func ListReviews(owner, repo string, num int) ([]*github.PullRequestReview, error) {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, err := actions.ListPullRequestReviews(client, owner, repo, num)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fetched %d review(s)", len(reviews))
+
+	return reviews, nil
+}
+
+func ListTeamMembers(org, slug string) ([]string, error) {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return actions.ListTeamMemberLogins(client, org, slug)
+}
+
+func CreateCheckRun(owner, repo string, opt github.CreateCheckRunOptions) error {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.Checks.CreateCheckRun(context.Background(), owner, repo, opt)
+
+	return err
+}
+
+func GetIssue(owner, repo string, num int) (*github.Issue, error) {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	issue, _, err := client.Issues.Get(context.Background(), owner, repo, num)
+
+	return issue, err
+}
+
+func ListCommits(owner, repo string, num int) ([]*github.RepositoryCommit, error) {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return actions.ListPullRequestCommits(client, owner, repo, num)
+}
+
+func GetPushedAt(owner, repo string, pr *github.PullRequest) (time.Time, error) {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return actions.PushedAt(client, owner, repo, pr)
+}
+
+func ListFiles(owner, repo string, num int) ([]*github.CommitFile, error) {
+	client, err := actions.CreateClient(os.Getenv("GITHUB_TOKEN"), "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return actions.ListPullRequestFiles(client, owner, repo, num)
+}
+
 func formatFailures(failures []string) string {
 	var lines []string
 	for _, f := range failures {
@@ -0,0 +1,208 @@
+package pullvet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+)
+
+// Formats of the report
+const (
+	OutputText     = "text"
+	OutputJSON     = "json"
+	OutputMarkdown = "markdown"
+	// OutputCSV is supported only with AllOpen
+	OutputCSV = "csv"
+)
+
+// Report is the result of evaluating every requirement and rule against the pull request
+type Report struct {
+	// Passed is true when the pull request passed pullvet as a whole, according to RequireAny and RequireAll
+	Passed  bool     `json:"passed"`
+	Results []Result `json:"results"`
+	// Failures are the reasons pullvet failed, shown in the text output
+	Failures []string `json:"failures,omitempty"`
+	// Skipped is the reason pullvet skipped evaluating the pull request, if it did
+	Skipped string `json:"skipped,omitempty"`
+
+	numPassed int
+	// fixes are the fixes declared for the failed rules
+	fixes []ruleFix
+}
+
+// Result is the result of a requirement or a rule
+type Result struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	// Skipped is true when the rule didn't apply to the pull request
+	Skipped  bool   `json:"skipped,omitempty"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func (r *Report) add(name string, passed bool, expected, actual string) {
+	r.Results = append(r.Results, Result{Name: name, Passed: passed, Expected: expected, Actual: actual})
+}
+
+// Err returns the error describing the failures, or nil if the pull request passed
+func (r *Report) Err() error {
+	if r.Passed {
+		return nil
+	}
+	return fmt.Errorf("%d check(s) failed:\n%s\n", len(r.Failures), formatFailures(r.Failures))
+}
+
+// Title summarizes the report in a line
+func (r *Report) Title() string {
+	if r.Skipped != "" {
+		return "skipped: " + r.Skipped
+	}
+	if r.Passed {
+		return fmt.Sprintf("%d check(s) passed", r.numPassed)
+	}
+	return fmt.Sprintf("%d check(s) failed", len(r.Failures))
+}
+
+// Markdown renders the report as a table of results, followed by the failures if any
+func (r *Report) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "### pullvet: %s\n\n", r.Title())
+
+	if r.Skipped != "" {
+		return b.String()
+	}
+
+	b.WriteString("| | Check | Expected | Actual |\n")
+	b.WriteString("|---|---|---|---|\n")
+
+	for _, res := range r.Results {
+		mark := ":x:"
+		switch {
+		case res.Skipped:
+			mark = ":fast_forward:"
+		case res.Passed:
+			mark = ":white_check_mark:"
+		}
+
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", mark, markdownCell(res.Name), markdownCode(res.Expected), markdownCell(res.Actual))
+	}
+
+	if !r.Passed {
+		b.WriteString("\n<details><summary>Failures</summary>\n\n```\n")
+		b.WriteString(formatFailures(r.Failures))
+		b.WriteString("\n```\n\n</details>\n")
+	}
+
+	return b.String()
+}
+
+func markdownCell(s string) string {
+	s = strings.Replace(s, "|", "\\|", -1)
+	return strings.Replace(s, "\n", "<br>", -1)
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownCell(strings.Replace(s, "`", "'", -1)) + "`"
+}
+
+// writeReport writes the report in the format specified by Output
+func (c *Action) writeReport(w io.Writer, r *Report) error {
+	switch c.Output {
+	case OutputText, "":
+		if r.Passed {
+			_, err := fmt.Fprintf(w, "%s\n", r.Title())
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%s\n", r.Err().Error())
+		return err
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case OutputMarkdown:
+		_, err := io.WriteString(w, r.Markdown())
+		return err
+	default:
+		return fmt.Errorf("unsupported output %q: expected any of %q, %q and %q", c.Output, OutputText, OutputJSON, OutputMarkdown)
+	}
+}
+
+// publish publishes the report as the check run and the commit status, according to CheckRunName and StatusContext
+func (c *Action) publish(owner, repo string, pullRequest *github.PullRequest, r *Report) error {
+	if c.CheckRunName == "" && c.StatusContext == "" {
+		return nil
+	}
+
+	if owner == "" {
+		var err error
+		owner, repo, err = actions.OwnerRepo()
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.CheckRunName != "" {
+		if err := c.publishCheckRun(owner, repo, pullRequest, r); err != nil {
+			return err
+		}
+	}
+
+	if c.StatusContext != "" {
+		state := "success"
+		if !r.Passed {
+			state = "failure"
+		}
+
+		desc := r.Title()
+		if !r.Passed && len(r.Failures) > 0 {
+			desc += ": " + strings.SplitN(r.Failures[0], "\n", 2)[0]
+		}
+		desc = actions.Truncate(desc, actions.MaxDescriptionLength)
+
+		status := &github.RepoStatus{
+			State:       github.String(state),
+			Description: github.String(desc),
+			Context:     github.String(c.StatusContext),
+		}
+
+		if err := c.CreateStatus(owner, repo, pullRequest.GetHead().GetSHA(), status); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publishCheckRun publishes the report as a completed check run for the head of the pull request
+func (c *Action) publishCheckRun(owner, repo string, pullRequest *github.PullRequest, r *Report) error {
+	conclusion := "success"
+	switch {
+	case r.Skipped != "":
+		conclusion = "neutral"
+	case !r.Passed:
+		conclusion = "failure"
+	}
+
+	opt := github.CreateCheckRunOptions{
+		Name:        c.CheckRunName,
+		HeadSHA:     pullRequest.GetHead().GetSHA(),
+		Status:      github.String("completed"),
+		Conclusion:  github.String(conclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output: &github.CheckRunOutput{
+			Title:   github.String(r.Title()),
+			Summary: github.String(r.Markdown()),
+		},
+	}
+
+	return c.CreateCheckRun(owner, repo, opt)
+}
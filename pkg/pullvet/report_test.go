@@ -0,0 +1,121 @@
+package pullvet
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestReport(t *testing.T) {
+	var checkRuns []github.CreateCheckRunOptions
+
+	cmd := &Action{
+		RequireAll: true,
+		Labels:     []string{"v1"},
+		RuleFlags:  []string{`sized=label_match("size/.+") && !label("wip")`, `hotfix=label("hotfix")`},
+		NoteRegex:  DefaultNoteRegex,
+		GetPullRequestBody: func(owner, repo string, num int) (string, error) {
+			return "", nil
+		},
+		CheckRunName: "pullvet",
+		CreateCheckRun: func(owner, repo string, opt github.CreateCheckRunOptions) error {
+			checkRuns = append(checkRuns, opt)
+			return nil
+		},
+	}
+
+	cmd.Rules = []Rule{{Name: "released", When: `base("^release-.+")`, Expr: `any_milestone()`}}
+
+	input := &github.PullRequest{
+		Head:   &github.PullRequestBranch{SHA: github.String("head")},
+		Base:   &github.PullRequestBranch{Ref: github.String("master")},
+		Labels: []*github.Label{{Name: github.String("v1")}, {Name: github.String("wip")}},
+	}
+
+	report, err := cmd.Evaluate("myuser", "myrepo", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Result{
+		{Name: "label", Passed: true, Expected: "v1", Actual: `["v1", "wip"]`},
+		{Name: "released", Passed: true, Skipped: true, Expected: `any_milestone()`},
+		{Name: "sized", Passed: false, Expected: `label_match("size/.+") && !label("wip")`, Actual: `label_match("size/.+"): labels were ["v1", "wip"]; !label("wip")`},
+		{Name: "hotfix", Passed: false, Expected: `label("hotfix")`, Actual: `label("hotfix"): labels were ["v1", "wip"]`},
+	}
+
+	if !reflect.DeepEqual(report.Results, expected) {
+		t.Errorf("unexpected results: expected=%+v, got=%+v", expected, report.Results)
+	}
+
+	if report.Passed {
+		t.Errorf("unexpected pass")
+	}
+
+	var buf bytes.Buffer
+
+	cmd.Output = OutputJSON
+	if err := cmd.writeReport(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded.Results, expected) || len(decoded.Failures) != 2 {
+		t.Errorf("unexpected json: %s", buf.String())
+	}
+
+	md := report.Markdown()
+	for _, s := range []string{
+		"### pullvet: 2 check(s) failed",
+		"| :white_check_mark: | label | `v1` | [\"v1\", \"wip\"] |",
+		"| :fast_forward: | released | `any_milestone()` |  |",
+		"| :x: | hotfix | `label(\"hotfix\")` | label(\"hotfix\"): labels were [\"v1\", \"wip\"] |",
+	} {
+		if !strings.Contains(md, s) {
+			t.Errorf("unexpected markdown: expected to contain %q, got:\n%s", s, md)
+		}
+	}
+
+	cmd.Output = OutputMarkdown
+	if err := cmd.HandlePullRequest("myuser", "myrepo", input); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+
+	if len(checkRuns) != 1 || checkRuns[0].GetConclusion() != "failure" || checkRuns[0].HeadSHA != "head" || checkRuns[0].Output.GetSummary() != md {
+		t.Errorf("unexpected check runs: %+v", checkRuns)
+	}
+}
+
+func TestPublishStatusDescription(t *testing.T) {
+	var statuses []*github.RepoStatus
+
+	cmd := &Action{
+		StatusContext: "pullvet",
+		CreateStatus: func(owner, repo, ref string, status *github.RepoStatus) error {
+			statuses = append(statuses, status)
+			return nil
+		},
+	}
+
+	input := &github.PullRequest{Head: &github.PullRequestBranch{SHA: github.String("head")}}
+
+	// The description is truncated by characters, never splitting multi-byte ones
+	report := &Report{Failures: []string{strings.Repeat("レビュー", 40) + "\nsecond line"}}
+
+	if err := cmd.publish("myuser", "myrepo", input, report); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "1 check(s) failed: " + strings.Repeat("レビュー", 29) + "レビ..."
+	if len(statuses) != 1 || statuses[0].GetDescription() != expected || statuses[0].GetState() != "failure" {
+		t.Errorf("unexpected statuses: expected description %q, got %+v", expected, statuses)
+	}
+}
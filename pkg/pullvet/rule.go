@@ -0,0 +1,130 @@
+package pullvet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule is a named expression that must hold for the pull request, along with the message shown when it doesn't
+type Rule struct {
+	Name    string `yaml:"name"`
+	Message string `yaml:"message"`
+	// When is the expression that limits the pull requests the rule applies to. The rule applies to every pull request when empty
+	When string `yaml:"when"`
+	Expr string `yaml:"expr"`
+	// Fix is applied when the rule failed in the -fix mode
+	Fix *Fix `yaml:"fix"`
+}
+
+// ParseRuleFlag parses the rule given in the `NAME=EXPR` form
+func ParseRuleFlag(s string) (Rule, error) {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+		return Rule{}, fmt.Errorf("unexpected format of rule %q: expected NAME=EXPR", s)
+	}
+	return Rule{Name: strings.TrimSpace(kv[0]), Expr: kv[1]}, nil
+}
+
+// rules returns the rules given via the Rules field, the -rule flags, the convention flags, the label set flags, the task list flags and the time-based flags, with messages given via the -rule-message flags
+func (c *Action) rules() ([]Rule, error) {
+	rules := append([]Rule{}, c.Rules...)
+
+	for _, s := range c.RuleFlags {
+		r, err := ParseRuleFlag(s)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+
+	for _, s := range c.WhenChangedFlags {
+		kv := strings.SplitN(s, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("unexpected format of %q: expected GLOB=EXPR", s)
+		}
+		rules = append(rules, Rule{
+			Name: "when changed " + kv[0],
+			When: "changed(" + quoteArg(kv[0]) + ")",
+			Expr: kv[1],
+		})
+	}
+
+	rules = append(rules, c.conventionRules()...)
+
+	labelRules, err := c.labelRules()
+	if err != nil {
+		return nil, err
+	}
+	rules = append(rules, labelRules...)
+
+	rules = append(rules, c.taskRules()...)
+
+	rules = append(rules, c.timingRules()...)
+
+	for _, s := range c.RuleMessageFlags {
+		kv := strings.SplitN(s, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("unexpected format of rule message %q: expected NAME=MESSAGE", s)
+		}
+
+		var found bool
+		for i := range rules {
+			if rules[i].Name == kv[0] {
+				rules[i].Message = kv[1]
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("rule message %q given for undefined rule %q", kv[1], kv[0])
+		}
+	}
+
+	return rules, nil
+}
+
+// applies returns true when the rule applies to the pull request, according to the When expression
+func (r Rule) applies(f *facts) (bool, error) {
+	if r.When == "" {
+		return true, nil
+	}
+
+	e, err := ParseExpr(r.When)
+	if err != nil {
+		return false, fmt.Errorf("rule %q: when: %v", r.Name, err)
+	}
+
+	ev, err := e.eval(f)
+	if err != nil {
+		return false, fmt.Errorf("rule %q: when: %v", r.Name, err)
+	}
+
+	return ev.passed, nil
+}
+
+func (r Rule) evaluate(f *facts) (*evaluation, error) {
+	e, err := ParseExpr(r.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %v", r.Name, err)
+	}
+
+	ev, err := e.eval(f)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %v", r.Name, err)
+	}
+
+	return ev, nil
+}
+
+// failureReason describes the failure of the rule in a phrase
+func (r Rule) failureReason() string {
+	reason := fmt.Sprintf("rule %q failed", r.Name)
+	if r.Message != "" {
+		reason += ": " + r.Message
+	}
+	return reason
+}
+
+// formatRuleFailure describes the failed rule, showing which branch of the expression failed
+func formatRuleFailure(r Rule, ev *evaluation) string {
+	return r.failureReason() + "\n" + ev.format("    ")
+}
@@ -0,0 +1,85 @@
+package pullvet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isBot returns true when the author of the pull request is a bot like dependabot[bot]
+func (f *facts) isBot() bool {
+	user := f.pr.GetUser()
+	return user.GetType() == "Bot" || strings.HasSuffix(user.GetLogin(), "[bot]")
+}
+
+// draftPredicate holds when the pull request is a draft
+func draftPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		if f.pr.GetDraft() {
+			return true, "", nil
+		}
+		return false, "ready for review", nil
+	}, nil
+}
+
+// authorAssociationPredicate holds when the author association of the pull request is any of the arguments, like MEMBER
+func authorAssociationPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, -1); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		assoc := f.pr.GetAuthorAssociation()
+		for _, a := range args {
+			if strings.EqualFold(a, assoc) {
+				return true, "", nil
+			}
+		}
+		return false, fmt.Sprintf("author association was %q", assoc), nil
+	}, nil
+}
+
+// botPredicate holds when the author of the pull request is a bot
+func botPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		if f.isBot() {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("author %q is not a bot", f.pr.GetUser().GetLogin()), nil
+	}, nil
+}
+
+// skipReason returns why pullvet skips the pull request, or an empty string if it doesn't
+func (c *Action) skipReason(f *facts) (string, error) {
+	author := f.pr.GetUser().GetLogin()
+
+	if contains(c.SkipAuthors, author) {
+		return fmt.Sprintf("author %q is skipped", author), nil
+	}
+
+	if c.SkipBots && f.isBot() {
+		return fmt.Sprintf("author %q is a bot", author), nil
+	}
+
+	for _, s := range c.SkipIf {
+		e, err := ParseExpr(s)
+		if err != nil {
+			return "", fmt.Errorf("skip-if %q: %v", s, err)
+		}
+
+		ev, err := e.eval(f)
+		if err != nil {
+			return "", fmt.Errorf("skip-if %q: %v", s, err)
+		}
+
+		if ev.passed {
+			return fmt.Sprintf("%s held", s), nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,112 @@
+package pullvet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestScope(t *testing.T) {
+	config := `rulesets:
+- name: ready
+  when: "!draft()"
+  rules:
+  - name: sized
+    expr: label_match("size/.+")
+  - name: released
+    when: base("^release-.+")
+    expr: any_milestone()
+`
+
+	pr := func(login, userType, assoc string, draft bool) *github.PullRequest {
+		return &github.PullRequest{
+			User:              &github.User{Login: github.String(login), Type: github.String(userType)},
+			AuthorAssociation: github.String(assoc),
+			Draft:             github.Bool(draft),
+			Base:              &github.PullRequestBranch{Ref: github.String("release-1")},
+		}
+	}
+
+	testcases := []struct {
+		cmd      *Action
+		pr       *github.PullRequest
+		skipped  string
+		expected string
+	}{
+		{
+			cmd:     &Action{Labels: []string{"approved"}, SkipBots: true},
+			pr:      pr("dependabot[bot]", "Bot", "NONE", false),
+			skipped: "author \"dependabot[bot]\" is a bot",
+		},
+		{
+			cmd:      &Action{Labels: []string{"approved"}, SkipBots: true},
+			pr:       pr("mumoshu", "User", "OWNER", false),
+			expected: "missing label: approved",
+		},
+		{
+			cmd:     &Action{Labels: []string{"approved"}, SkipAuthors: []string{"renovate"}},
+			pr:      pr("renovate", "User", "CONTRIBUTOR", false),
+			skipped: "author \"renovate\" is skipped",
+		},
+		{
+			cmd:     &Action{Labels: []string{"approved"}, SkipIf: []string{`draft() && !label("ready")`}},
+			pr:      pr("mumoshu", "User", "OWNER", true),
+			skipped: "draft() && !label(\"ready\") held",
+		},
+		{
+			cmd: &Action{
+				Rules: []Rule{{Name: "first timers", When: `author_association("FIRST_TIME_CONTRIBUTOR", "FIRST_TIMER")`, Expr: `label("maintainer-approved")`}},
+			},
+			pr:       pr("newcomer", "User", "FIRST_TIME_CONTRIBUTOR", false),
+			expected: "rule \"first timers\" failed",
+		},
+		{
+			cmd: &Action{
+				Rules: []Rule{{Name: "first timers", When: `author_association("FIRST_TIME_CONTRIBUTOR", "FIRST_TIMER")`, Expr: `label("maintainer-approved")`}},
+			},
+			pr: pr("mumoshu", "User", "MEMBER", false),
+		},
+		{
+			cmd: &Action{ConfigFile: ".github/pullvet.yaml"},
+			pr:  pr("mumoshu", "User", "MEMBER", true),
+		},
+		{
+			cmd:      &Action{ConfigFile: ".github/pullvet.yaml"},
+			pr:       pr("mumoshu", "User", "MEMBER", false),
+			expected: "2 check(s) failed:\n* rule \"sized\" failed",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		tc.cmd.RequireAny = true
+		tc.cmd.NoteRegex = DefaultNoteRegex
+		tc.cmd.GetPullRequestBody = func(owner, repo string, num int) (string, error) {
+			return "", nil
+		}
+		tc.cmd.GetFileContent = func(owner, repo, ref, path string) (string, error) {
+			return config, nil
+		}
+
+		report, err := tc.cmd.Evaluate("myuser", "myrepo", tc.pr)
+		if err != nil {
+			t.Fatalf("testcases[%d]: %v", i, err)
+		}
+
+		if report.Skipped != tc.skipped {
+			t.Errorf("testcases[%d]: unexpected skip: expected=%q, got=%q", i, tc.skipped, report.Skipped)
+		}
+
+		err = report.Err()
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
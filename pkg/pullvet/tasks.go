@@ -0,0 +1,144 @@
+package pullvet
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// taskRegex matches task list items like `- [ ] Tests added` and `* [x] Docs updated`
+	taskRegex = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.+?)\s*$`)
+	// headingRegex matches ATX headings like `## Checklist`
+	headingRegex = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	// htmlCommentRegex matches HTML comments, that are often used for instructions in pull request templates
+	htmlCommentRegex = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+// Task is a task list item in the pull request description
+type Task struct {
+	Text    string
+	Checked bool
+	// Sections are the titles of the headings the task is under, from the outermost one
+	Sections []string
+}
+
+// ParseTasks returns the task list items in the Markdown text, skipping ones in HTML comments
+func ParseTasks(body string) []Task {
+	var tasks []Task
+
+	type heading struct {
+		level int
+		title string
+	}
+
+	var headings []heading
+
+	for _, line := range strings.Split(htmlCommentRegex.ReplaceAllString(normalizeNewlines(body), ""), "\n") {
+		if m := headingRegex.FindStringSubmatch(line); m != nil {
+			level := len(m[1])
+			for len(headings) > 0 && headings[len(headings)-1].level >= level {
+				headings = headings[:len(headings)-1]
+			}
+			headings = append(headings, heading{level: level, title: m[2]})
+			continue
+		}
+
+		if m := taskRegex.FindStringSubmatch(line); m != nil {
+			var sections []string
+			for _, h := range headings {
+				sections = append(sections, h.title)
+			}
+			tasks = append(tasks, Task{Text: m[2], Checked: m[1] != " ", Sections: sections})
+		}
+	}
+
+	return tasks
+}
+
+// hasSection returns true when the Markdown text has the heading with the title
+func hasSection(body, title string) bool {
+	for _, line := range strings.Split(htmlCommentRegex.ReplaceAllString(normalizeNewlines(body), ""), "\n") {
+		if m := headingRegex.FindStringSubmatch(line); m != nil && strings.EqualFold(m[2], title) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Task) in(section string) bool {
+	for _, s := range t.Sections {
+		if strings.EqualFold(s, section) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkedPredicate holds when the task list item with the text is checked
+func checkedPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		var found bool
+		for _, t := range ParseTasks(f.body) {
+			if !strings.EqualFold(t.Text, args[0]) {
+				continue
+			}
+			if t.Checked {
+				return true, "", nil
+			}
+			found = true
+		}
+		if !found {
+			return false, fmt.Sprintf("missing task %q", args[0]), nil
+		}
+		return false, fmt.Sprintf("unchecked task %q", args[0]), nil
+	}, nil
+}
+
+// allCheckedInSectionPredicate holds when the section exists, and every task list item under the section is checked
+func allCheckedInSectionPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		if !hasSection(f.body, args[0]) {
+			return false, fmt.Sprintf("missing section %q", args[0]), nil
+		}
+		var found bool
+		var unchecked []string
+		for _, t := range ParseTasks(f.body) {
+			if !t.in(args[0]) {
+				continue
+			}
+			found = true
+			if !t.Checked {
+				unchecked = append(unchecked, t.Text)
+			}
+		}
+		if !found {
+			return false, fmt.Sprintf("missing tasks in section %q", args[0]), nil
+		}
+		if len(unchecked) > 0 {
+			return false, fmt.Sprintf("unchecked tasks %s", quoteList(unchecked)), nil
+		}
+		return true, "", nil
+	}, nil
+}
+
+// taskRules returns the rules synthesized from the task list flags
+func (c *Action) taskRules() []Rule {
+	var rules []Rule
+
+	for _, t := range c.RequireChecked {
+		rules = append(rules, Rule{Name: "checked " + t, Expr: "checked(" + quoteArg(t) + ")"})
+	}
+
+	for _, s := range c.RequireAllCheckedInSections {
+		rules = append(rules, Rule{Name: "all checked in " + s, Expr: "all_checked_in_section(" + quoteArg(s) + ")"})
+	}
+
+	return rules
+}
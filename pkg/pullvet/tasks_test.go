@@ -0,0 +1,88 @@
+package pullvet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+const taskBody = "## Summary\r\n\r\nFixes the bug\r\n\r\n## Checklist\r\n\r\n- [x] Tests added\r\n- [ ] Docs updated\r\n\r\n### Release\r\n\r\n* [X] Changelog updated\r\n\r\n<!--\r\n- [ ] Hidden\r\n-->\r\n\r\n## Notes\r\n\r\n- [ ] Follow-up\r\n"
+
+func TestParseTasks(t *testing.T) {
+	expected := []Task{
+		{Text: "Tests added", Checked: true, Sections: []string{"Checklist"}},
+		{Text: "Docs updated", Checked: false, Sections: []string{"Checklist"}},
+		{Text: "Changelog updated", Checked: true, Sections: []string{"Checklist", "Release"}},
+		{Text: "Follow-up", Checked: false, Sections: []string{"Notes"}},
+	}
+
+	actual := ParseTasks(taskBody)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected tasks: expected=%+v, got=%+v", expected, actual)
+	}
+}
+
+func TestTaskRules(t *testing.T) {
+	testcases := []struct {
+		cmd      *Action
+		body     string
+		expected string
+	}{
+		{
+			cmd:  &Action{RequireChecked: []string{"Tests added"}},
+			body: taskBody,
+		},
+		{
+			cmd:      &Action{RequireChecked: []string{"Docs updated"}},
+			body:     taskBody,
+			expected: "[fail] checked(\"Docs updated\"): unchecked task \"Docs updated\"",
+		},
+		{
+			cmd:      &Action{RequireChecked: []string{"Hidden"}},
+			body:     taskBody,
+			expected: "[fail] checked(\"Hidden\"): missing task \"Hidden\"",
+		},
+		{
+			cmd:  &Action{RequireAllCheckedInSections: []string{"Release"}},
+			body: taskBody,
+		},
+		{
+			cmd:      &Action{RequireAllCheckedInSections: []string{"Checklist"}},
+			body:     taskBody,
+			expected: "rule \"all checked in Checklist\" failed\n    [fail] all_checked_in_section(\"Checklist\"): unchecked tasks [\"Docs updated\"]",
+		},
+		{
+			cmd:      &Action{RequireAllCheckedInSections: []string{"Checklist"}},
+			body:     "## Summary\n\nFixes the bug\n",
+			expected: "[fail] all_checked_in_section(\"Checklist\"): missing section \"Checklist\"",
+		},
+		{
+			cmd:      &Action{RequireAllCheckedInSections: []string{"Checklist"}},
+			body:     "## Checklist\n\nNothing to check\n",
+			expected: "[fail] all_checked_in_section(\"Checklist\"): missing tasks in section \"Checklist\"",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		tc.cmd.RequireAny = true
+		tc.cmd.NoteRegex = DefaultNoteRegex
+		tc.cmd.GetPullRequestBody = func(owner, repo string, num int) (string, error) {
+			return tc.body, nil
+		}
+
+		err := tc.cmd.HandlePullRequest("myuser", "myrepo", &github.PullRequest{})
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package pullvet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/variantdev/go-actions/pkg/freeze"
+)
+
+// now returns the current time from Now, so that tests can inject the clock
+func (c *Action) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// lastPushedAt returns when the head of the pull request was pushed to GitHub
+func (f *facts) lastPushedAt() (time.Time, error) {
+	return f.action.GetPushedAt(f.owner, f.repo, f.pr)
+}
+
+// freezeConfig returns the freeze file, read from the same source as the config file
+func (f *facts) freezeConfig() (*freeze.Config, error) {
+	if f.freeze != nil {
+		return f.freeze, nil
+	}
+
+	if f.action.FreezeFile == "" {
+		return nil, fmt.Errorf("missing freeze file")
+	}
+
+	bs, err := f.action.readFile(f.owner, f.repo, f.pr, f.action.FreezeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := freeze.Parse(bs)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", f.action.FreezeFile, err)
+	}
+
+	f.freeze = conf
+
+	return conf, nil
+}
+
+func parseDurationArg(args []string) (time.Duration, error) {
+	if err := requireArgs(args, 1, 1); err != nil {
+		return 0, err
+	}
+	return time.ParseDuration(args[0])
+}
+
+// minOpenPredicate holds when the pull request has been open for the duration or longer
+func minOpenPredicate(args []string) (predicateFunc, error) {
+	d, err := parseDurationArg(args)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		open := f.action.now().Sub(f.pr.GetCreatedAt())
+		if open >= d {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("open for %s", open.Truncate(time.Minute)), nil
+	}, nil
+}
+
+// minSincePushPredicate holds when the duration or longer has passed since the last push to the pull request
+func minSincePushPredicate(args []string) (predicateFunc, error) {
+	d, err := parseDurationArg(args)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		pushed, err := f.lastPushedAt()
+		if err != nil {
+			return false, "", err
+		}
+		since := f.action.now().Sub(pushed)
+		if since >= d {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("last pushed %s ago", since.Truncate(time.Minute)), nil
+	}, nil
+}
+
+// notFrozenPredicate holds when no freeze window in the freeze file is active, or the pull request has the exception label
+func notFrozenPredicate(args []string) (predicateFunc, error) {
+	if err := requireArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return func(f *facts) (bool, string, error) {
+		conf, err := f.freezeConfig()
+		if err != nil {
+			return false, "", err
+		}
+		if err := conf.Check(f.action.now(), f.labels); err != nil {
+			return false, err.Error(), nil
+		}
+		return true, "", nil
+	}, nil
+}
+
+// timingRules returns the rules synthesized from the time-based flags
+func (c *Action) timingRules() []Rule {
+	var rules []Rule
+
+	if c.MinOpen > 0 {
+		pred := "min_open"
+		if c.MinOpenSincePush {
+			pred = "min_since_push"
+		}
+		rules = append(rules, Rule{Name: "min open", Expr: pred + "(" + quoteArg(c.MinOpen.String()) + ")"})
+	}
+
+	if c.FreezeFile != "" {
+		rules = append(rules, Rule{Name: "freeze", Expr: "not_frozen()"})
+	}
+
+	return rules
+}
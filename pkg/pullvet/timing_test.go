@@ -0,0 +1,91 @@
+package pullvet
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestTimingRules(t *testing.T) {
+	// Tuesday
+	now := time.Date(2019, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	freezeFile := `windows:
+- name: weekends
+  cron: "* * * * 6,0"
+- name: holidays
+  start: "2019-10-14"
+  end: "2019-10-18"
+`
+
+	stubPushedAt := func(pushed time.Time) func(owner, repo string, pr *github.PullRequest) (time.Time, error) {
+		return func(owner, repo string, pr *github.PullRequest) (time.Time, error) {
+			return pushed, nil
+		}
+	}
+
+	testcases := []struct {
+		cmd      *Action
+		created  time.Time
+		labels   []string
+		expected string
+	}{
+		{
+			cmd:     &Action{MinOpen: 24 * time.Hour},
+			created: now.Add(-25 * time.Hour),
+		},
+		{
+			cmd:      &Action{MinOpen: 24 * time.Hour},
+			created:  now.Add(-90 * time.Minute),
+			expected: "rule \"min open\" failed\n    [fail] min_open(\"24h0m0s\"): open for 1h30m0s",
+		},
+		{
+			cmd:      &Action{MinOpen: 24 * time.Hour, MinOpenSincePush: true, GetPushedAt: stubPushedAt(now.Add(-2 * time.Hour))},
+			created:  now.Add(-72 * time.Hour),
+			expected: "[fail] min_since_push(\"24h0m0s\"): last pushed 2h0m0s ago",
+		},
+		{
+			cmd:     &Action{MinOpen: 24 * time.Hour, MinOpenSincePush: true, GetPushedAt: stubPushedAt(now.Add(-48 * time.Hour))},
+			created: now.Add(-72 * time.Hour),
+		},
+		{
+			cmd:      &Action{FreezeFile: ".github/freeze.yaml"},
+			expected: "[fail] not_frozen(): merges are frozen during window \"holidays\"",
+		},
+		{
+			cmd:    &Action{FreezeFile: ".github/freeze.yaml"},
+			labels: []string{"freeze-exception"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		tc.cmd.RequireAny = true
+		tc.cmd.NoteRegex = DefaultNoteRegex
+		tc.cmd.Now = func() time.Time { return now }
+		tc.cmd.GetPullRequestBody = func(owner, repo string, num int) (string, error) {
+			return "", nil
+		}
+		tc.cmd.GetFileContent = func(owner, repo, ref, path string) (string, error) {
+			return freezeFile, nil
+		}
+
+		pr := &github.PullRequest{CreatedAt: &tc.created}
+		for _, l := range tc.labels {
+			pr.Labels = append(pr.Labels, &github.Label{Name: github.String(l)})
+		}
+
+		err := tc.cmd.HandlePullRequest("myuser", "myrepo", pr)
+
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("testcases[%d]: unexpected error: expected=%q, got=%q", i, tc.expected, err)
+		}
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("testcases[%d]: unexpected error: %v", i, err)
+		}
+	}
+}
@@ -3,14 +3,21 @@ package rebase
 import (
 	"context"
 	"flag"
+	"fmt"
+	"log"
 	"os"
 
 	"github.com/google/go-github/v28/github"
 	"github.com/variantdev/go-actions"
+	"github.com/variantdev/go-actions/pkg/statusmirror"
 )
 
 type Action struct {
 	BaseURL, UploadURL string
+
+	MirrorStatuses     bool
+	MirrorContexts     actions.StringSlice
+	MirrorSameTreeOnly bool
 }
 
 type Target struct {
@@ -28,6 +35,9 @@ func New() *Action {
 func (c *Action) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&c.BaseURL, "github-base-url", "", "")
 	fs.StringVar(&c.UploadURL, "github-upload-url", "", "")
+	fs.BoolVar(&c.MirrorStatuses, "mirror-statuses", false, "Copy commit statuses and completed check runs from the previous head to the rebased head")
+	fs.Var(&c.MirrorContexts, "mirror-context", "Regexp pattern to match status contexts and check run names to be mirrored against. Specify multiple times to mirror two or more. By default, everything is mirrored")
+	fs.BoolVar(&c.MirrorSameTreeOnly, "mirror-same-tree-only", true, "Mirror only when the rebased head has the same tree as the previous head")
 }
 
 func (c *Action) Run() error {
@@ -64,22 +74,30 @@ func (c *Action) ForcePushRebased(pre *Target) error {
 
 	commits := comparison.Commits
 
-	tempBranch := "refs/heads/temp"
+	// The temporary branch is unique to the pull request and its head, so that rebasing other pull requests or a later head never conflicts with it
+	tempBranch := fmt.Sprintf("go-actions/rebase-%d-%s", pr.GetNumber(), pr.Head.GetSHA())
+	tempRef := "heads/" + tempBranch
 
-	latestBaseRef, _, err := client.Git.GetRef(context.Background(), owner, repo, pr.Base.GetRef())
+	latestBaseRef, _, err := client.Git.GetRef(context.Background(), owner, repo, "heads/"+pr.Base.GetRef())
 	if err != nil {
 		return err
 	}
 
 	newHeadStart := latestBaseRef.Object.GetSHA()
 
-	parentOfNextChange := pr.Head.GetSHA()
+	parentOfNextChange := comparison.MergeBaseCommit.GetSHA()
 
-	_, _, err = client.Git.CreateRef(context.Background(), owner, repo, &github.Reference{Ref: github.String(tempBranch), Object: &github.GitObject{SHA: github.String(newHeadStart)}})
+	_, _, err = client.Git.CreateRef(context.Background(), owner, repo, &github.Reference{Ref: github.String("refs/" + tempRef), Object: &github.GitObject{SHA: github.String(newHeadStart)}})
 	if err != nil {
 		return err
 	}
 
+	defer func() {
+		if _, err := client.Git.DeleteRef(context.Background(), owner, repo, tempRef); err != nil {
+			log.Printf("Failed to delete the temporary branch %q: %v", tempBranch, err)
+		}
+	}()
+
 	newHeadCommit, _, err := client.Git.GetCommit(context.Background(), owner, repo, newHeadStart)
 	if err != nil {
 		return err
@@ -87,10 +105,8 @@ func (c *Action) ForcePushRebased(pre *Target) error {
 
 	for i := 0; i < len(commits); i++ {
 		emptyCommit := &github.Commit{
-			Author:    &github.CommitAuthor{Name: github.String("go-actions")},
-			Committer: &github.CommitAuthor{Name: github.String("go-actions")},
-			Message:   github.String("rebase wip"),
-			Tree:      newHeadCommit.GetTree(),
+			Message: github.String("rebase wip"),
+			Tree:    newHeadCommit.GetTree(),
 			Parents: []github.Commit{
 				{
 					SHA: github.String(parentOfNextChange),
@@ -102,8 +118,9 @@ func (c *Action) ForcePushRebased(pre *Target) error {
 			return cpErr
 		}
 
-		ref := &github.Reference{Ref: github.String(tempBranch), Object: &github.GitObject{SHA: emptyCommitCreated.SHA}}
-		_, _, refErr := client.Git.UpdateRef(context.Background(), owner, repo, ref, false)
+		// The empty commit is a sibling of the rebased commits, not a descendant of them
+		ref := &github.Reference{Ref: github.String(tempRef), Object: &github.GitObject{SHA: emptyCommitCreated.SHA}}
+		_, _, refErr := client.Git.UpdateRef(context.Background(), owner, repo, ref, true)
 		if refErr != nil {
 			return refErr
 		}
@@ -118,6 +135,7 @@ func (c *Action) ForcePushRebased(pre *Target) error {
 		newEmptyCommit := &github.Commit{
 			Author:    pickedCommit.Commit.Author,
 			Committer: pickedCommit.Commit.Committer,
+			Message:   pickedCommit.Commit.Message,
 			Parents:   []github.Commit{{SHA: newHeadCommit.SHA}},
 			Tree:      mergeCommit.Commit.Tree,
 		}
@@ -126,7 +144,7 @@ func (c *Action) ForcePushRebased(pre *Target) error {
 			return err
 		}
 
-		_, _, err = client.Git.UpdateRef(context.Background(), owner, repo, &github.Reference{Ref: github.String(tempBranch), Object: &github.GitObject{SHA: newHeadCommit.SHA}}, true)
+		_, _, err = client.Git.UpdateRef(context.Background(), owner, repo, &github.Reference{Ref: github.String(tempRef), Object: &github.GitObject{SHA: newHeadCommit.SHA}}, true)
 		if err != nil {
 			return err
 		}
@@ -135,15 +153,34 @@ func (c *Action) ForcePushRebased(pre *Target) error {
 	}
 
 	refObj := &github.Reference{
-		Ref: github.String(pullHead),
+		Ref: github.String("heads/" + pullHead),
 		Object: &github.GitObject{
 			SHA: newHeadCommit.SHA,
 		},
 	}
 
 	_, _, refErr := client.Git.UpdateRef(context.Background(), owner, repo, refObj, true)
+	if refErr != nil {
+		return refErr
+	}
+
+	if c.MirrorStatuses {
+		mirror := &statusmirror.Action{
+			BaseURL:      c.BaseURL,
+			UploadURL:    c.UploadURL,
+			Contexts:     c.MirrorContexts,
+			SameTreeOnly: c.MirrorSameTreeOnly,
+		}
+
+		return mirror.Mirror(&statusmirror.Target{
+			Owner: owner,
+			Repo:  repo,
+			From:  pr.Head.GetSHA(),
+			To:    newHeadCommit.GetSHA(),
+		})
+	}
 
-	return refErr
+	return nil
 }
 
 func (c *Action) getClient() (*github.Client, error) {
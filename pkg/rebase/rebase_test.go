@@ -0,0 +1,138 @@
+package rebase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions/pkg/githubtest"
+)
+
+func TestForcePushRebased(t *testing.T) {
+	os.Setenv("GITHUB_TOKEN", "token")
+
+	s := githubtest.NewServer()
+	defer s.Close()
+
+	// requests are the writes to the repository, like "PATCH heads/feature c2'"
+	var requests []string
+	record := func(method, target, sha string) {
+		requests = append(requests, strings.TrimSpace(fmt.Sprintf("%s %s %s", method, target, sha)))
+	}
+
+	commit := func(sha, message string) *github.RepositoryCommit {
+		return &github.RepositoryCommit{SHA: github.String(sha), Commit: &github.Commit{Message: github.String(message)}}
+	}
+
+	s.Mux.HandleFunc("/repos/o/r/compare/base0...c2", githubtest.JSON(&github.CommitsComparison{
+		MergeBaseCommit: &github.RepositoryCommit{SHA: github.String("base0")},
+		Commits:         []github.RepositoryCommit{*commit("c1", "one"), *commit("c2", "two")},
+	}))
+
+	s.Mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		record(r.Method, req.Ref, req.SHA)
+		githubtest.JSON(&github.Reference{Ref: github.String(req.Ref)})(w, r)
+	})
+
+	s.Mux.HandleFunc("/repos/o/r/git/refs/", func(w http.ResponseWriter, r *http.Request) {
+		ref := strings.TrimPrefix(r.URL.Path, "/repos/o/r/git/refs/")
+
+		switch r.Method {
+		case "GET":
+			if ref != "heads/master" {
+				githubtest.NotFound(w, r)
+				return
+			}
+			githubtest.JSON(&github.Reference{Ref: github.String("refs/heads/master"), Object: &github.GitObject{SHA: github.String("base1")}})(w, r)
+		case "PATCH":
+			var req struct {
+				SHA   string `json:"sha"`
+				Force bool   `json:"force"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+			if !req.Force {
+				t.Errorf("unexpected update of %s without force", ref)
+			}
+			record(r.Method, ref, req.SHA)
+			githubtest.JSON(&github.Reference{Ref: github.String("refs/" + ref)})(w, r)
+		default:
+			record(r.Method, ref, "")
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	s.Mux.HandleFunc("/repos/o/r/git/commits/base1", githubtest.JSON(&github.Commit{SHA: github.String("base1"), Tree: &github.Tree{SHA: github.String("tree1")}}))
+
+	s.Mux.HandleFunc("/repos/o/r/git/commits", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Message string   `json:"message"`
+			Tree    string   `json:"tree"`
+			Parents []string `json:"parents"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+
+		sha := req.Message + "'"
+		if req.Message == "rebase wip" {
+			sha = "wip-" + req.Parents[0]
+		}
+		record(r.Method, "commit", fmt.Sprintf("%s parent=%s tree=%s", sha, req.Parents[0], req.Tree))
+		githubtest.JSON(&github.Commit{SHA: github.String(sha), Tree: &github.Tree{SHA: github.String("tree-" + sha)}})(w, r)
+	})
+
+	s.Mux.HandleFunc("/repos/o/r/merges", func(w http.ResponseWriter, r *http.Request) {
+		var req github.RepositoryMergeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		record(r.Method, "merge", fmt.Sprintf("%s into %s", req.GetHead(), req.GetBase()))
+		githubtest.JSON(&github.RepositoryCommit{Commit: &github.Commit{Tree: &github.Tree{SHA: github.String("merged-" + req.GetHead())}}})(w, r)
+	})
+
+	pr := &github.PullRequest{
+		Number: github.Int(1),
+		Base:   &github.PullRequestBranch{Ref: github.String("master"), SHA: github.String("base0")},
+		Head:   &github.PullRequestBranch{Ref: github.String("feature"), SHA: github.String("c2")},
+	}
+
+	cmd := &Action{BaseURL: s.BaseURL, UploadURL: s.BaseURL}
+	if err := cmd.ForcePushRebased(&Target{Owner: "o", Repo: "r", PullRequest: pr}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"POST refs/heads/go-actions/rebase-1-c2 base1",
+		"POST commit wip-base0 parent=base0 tree=tree1",
+		"PATCH heads/go-actions/rebase-1-c2 wip-base0",
+		"POST merge c1 into go-actions/rebase-1-c2",
+		"POST commit one' parent=base1 tree=merged-c1",
+		"PATCH heads/go-actions/rebase-1-c2 one'",
+		"POST commit wip-c1 parent=c1 tree=tree-one'",
+		"PATCH heads/go-actions/rebase-1-c2 wip-c1",
+		"POST merge c2 into go-actions/rebase-1-c2",
+		"POST commit two' parent=one' tree=merged-c2",
+		"PATCH heads/go-actions/rebase-1-c2 two'",
+		"PATCH heads/feature two'",
+		// The temporary branch is deleted so that the next rebase can create it again
+		"DELETE heads/go-actions/rebase-1-c2",
+	}
+
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("unexpected requests:\nexpected=%s\ngot=%s", strings.Join(expected, "\n"), strings.Join(requests, "\n"))
+	}
+}
@@ -0,0 +1,289 @@
+package statusmirror
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/variantdev/go-actions"
+)
+
+type Action struct {
+	BaseURL, UploadURL string
+
+	From, To string
+
+	// Contexts is the list of regexp patterns to match status contexts and check run names against.
+	// Every status and check run is mirrored when empty.
+	Contexts actions.StringSlice
+
+	SameTreeOnly bool
+}
+
+type Target struct {
+	Owner, Repo string
+	// From and To are the SHAs of the commits to mirror statuses and check runs from and to
+	From, To string
+}
+
+func New() *Action {
+	return &Action{
+		BaseURL:   "",
+		UploadURL: "",
+	}
+}
+
+func (c *Action) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.BaseURL, "github-base-url", "", "")
+	fs.StringVar(&c.UploadURL, "github-upload-url", "", "")
+	fs.StringVar(&c.From, "from", "", "SHA of the commit to copy statuses and check runs from. Defaults to the pull request head before the push that triggered the event")
+	fs.StringVar(&c.To, "to", "", "SHA of the commit to copy statuses and check runs to. Defaults to the pull request head")
+	fs.Var(&c.Contexts, "context", "Regexp pattern to match status contexts and check run names against. Specify multiple times to mirror two or more. By default, everything is mirrored")
+	fs.BoolVar(&c.SameTreeOnly, "same-tree-only", true, "Mirror only when both commits have the same tree")
+}
+
+func (c *Action) Run() error {
+	from, to := c.From, c.To
+
+	var owner, repo string
+
+	if from == "" || to == "" {
+		pr, o, r, err := actions.PullRequest()
+		if err != nil {
+			return err
+		}
+
+		owner, repo = o, r
+
+		if from == "" {
+			from = actions.PullRequestBeforeSHA()
+		}
+
+		if to == "" {
+			to = pr.Head.GetSHA()
+		}
+	} else {
+		o, r, err := actions.OwnerRepo()
+		if err != nil {
+			return err
+		}
+
+		owner, repo = o, r
+	}
+
+	if from == "" {
+		return fmt.Errorf("unable to determine the commit to mirror from. Specify it with -from")
+	}
+
+	return c.Mirror(&Target{
+		Owner: owner,
+		Repo:  repo,
+		From:  from,
+		To:    to,
+	})
+}
+
+// Mirror copies the latest completed commit statuses and check runs of the `From` commit to the `To` commit.
+// Statuses and check runs that already exist for the `To` commit are left as-is.
+func (c *Action) Mirror(target *Target) error {
+	client, err := c.getClient()
+	if err != nil {
+		return err
+	}
+
+	var patterns []*regexp.Regexp
+	for _, p := range c.Contexts {
+		r, err := regexp.Compile(p)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, r)
+	}
+
+	match := func(name string) bool {
+		if len(patterns) == 0 {
+			return true
+		}
+		for _, r := range patterns {
+			if r.MatchString(name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if c.SameTreeOnly {
+		same, err := sameTree(client, target)
+		if err != nil {
+			return err
+		}
+
+		if !same {
+			log.Printf("Skipped mirroring as %s and %s have different trees", target.From, target.To)
+			return nil
+		}
+	}
+
+	if err := c.mirrorStatuses(client, target, match); err != nil {
+		return err
+	}
+
+	return c.mirrorCheckRuns(client, target, match)
+}
+
+func sameTree(client *github.Client, target *Target) (bool, error) {
+	from, _, err := client.Git.GetCommit(context.Background(), target.Owner, target.Repo, target.From)
+	if err != nil {
+		return false, err
+	}
+
+	to, _, err := client.Git.GetCommit(context.Background(), target.Owner, target.Repo, target.To)
+	if err != nil {
+		return false, err
+	}
+
+	return from.GetTree().GetSHA() == to.GetTree().GetSHA(), nil
+}
+
+func (c *Action) mirrorStatuses(client *github.Client, target *Target, match func(string) bool) error {
+	from, err := actions.ListLatestStatuses(client, target.Owner, target.Repo, target.From)
+	if err != nil {
+		return err
+	}
+
+	to, err := actions.ListLatestStatuses(client, target.Owner, target.Repo, target.To)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range statusesToMirror(from, to, match) {
+		ctx := st.GetContext()
+
+		status := &github.RepoStatus{
+			State:       st.State,
+			Context:     st.Context,
+			Description: st.Description,
+			TargetURL:   st.TargetURL,
+		}
+
+		if _, _, err := client.Repositories.CreateStatus(context.Background(), target.Owner, target.Repo, target.To, status); err != nil {
+			return err
+		}
+
+		log.Printf("Mirrored status %q (%s) from %s to %s", ctx, st.GetState(), target.From, target.To)
+	}
+
+	return nil
+}
+
+func (c *Action) mirrorCheckRuns(client *github.Client, target *Target, match func(string) bool) error {
+	from, err := actions.ListLatestCheckRuns(client, target.Owner, target.Repo, target.From)
+	if err != nil {
+		return err
+	}
+
+	to, err := actions.ListLatestCheckRuns(client, target.Owner, target.Repo, target.To)
+	if err != nil {
+		return err
+	}
+
+	for _, run := range checkRunsToMirror(from, to, match) {
+		name := run.GetName()
+
+		opt := github.CreateCheckRunOptions{
+			Name:        name,
+			HeadSHA:     target.To,
+			DetailsURL:  run.DetailsURL,
+			ExternalID:  run.ExternalID,
+			Status:      github.String("completed"),
+			Conclusion:  run.Conclusion,
+			StartedAt:   run.StartedAt,
+			CompletedAt: run.CompletedAt,
+		}
+
+		// The title and the summary are required when the output is provided
+		if out := run.GetOutput(); out.GetTitle() != "" && out.GetSummary() != "" {
+			opt.Output = &github.CheckRunOutput{
+				Title:   out.Title,
+				Summary: out.Summary,
+				Text:    out.Text,
+			}
+		}
+
+		if _, _, err := client.Checks.CreateCheckRun(context.Background(), target.Owner, target.Repo, opt); err != nil {
+			return err
+		}
+
+		log.Printf("Mirrored check run %q (%s) from %s to %s", name, run.GetConclusion(), target.From, target.To)
+	}
+
+	return nil
+}
+
+// statusesToMirror returns the statuses of the `From` commit that are to be copied to the `To` commit.
+// Pending statuses are never mirrored, as nothing would update them on the `To` commit.
+func statusesToMirror(from, to []github.RepoStatus, match func(string) bool) []github.RepoStatus {
+	existing := map[string]struct{}{}
+	for _, st := range to {
+		existing[st.GetContext()] = struct{}{}
+	}
+
+	var mirrored []github.RepoStatus
+
+	for _, st := range from {
+		ctx := st.GetContext()
+
+		if !match(ctx) {
+			continue
+		}
+
+		if st.GetState() == "pending" {
+			log.Printf("Skipped mirroring status %q as it is pending", ctx)
+			continue
+		}
+
+		if _, ok := existing[ctx]; ok {
+			log.Printf("Skipped mirroring status %q as it already exists", ctx)
+			continue
+		}
+
+		mirrored = append(mirrored, st)
+	}
+
+	return mirrored
+}
+
+// checkRunsToMirror returns the completed check runs of the `From` commit that are to be copied to the `To` commit
+func checkRunsToMirror(from, to []*github.CheckRun, match func(string) bool) []*github.CheckRun {
+	existing := map[string]struct{}{}
+	for _, run := range to {
+		existing[run.GetName()] = struct{}{}
+	}
+
+	var mirrored []*github.CheckRun
+
+	for _, run := range from {
+		name := run.GetName()
+
+		if !match(name) || run.GetStatus() != "completed" {
+			continue
+		}
+
+		if _, ok := existing[name]; ok {
+			log.Printf("Skipped mirroring check run %q as it already exists", name)
+			continue
+		}
+
+		mirrored = append(mirrored, run)
+	}
+
+	return mirrored
+}
+
+func (c *Action) getClient() (*github.Client, error) {
+	return actions.CreateClient(os.Getenv("GITHUB_TOKEN"), c.BaseURL, c.UploadURL)
+}
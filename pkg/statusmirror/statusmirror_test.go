@@ -0,0 +1,98 @@
+package statusmirror
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestStatusesToMirror(t *testing.T) {
+	status := func(context, state string) github.RepoStatus {
+		return github.RepoStatus{Context: github.String(context), State: github.String(state)}
+	}
+
+	testcases := []struct {
+		from, to []github.RepoStatus
+		pattern  string
+		expected []string
+	}{
+		{
+			from:     []github.RepoStatus{status("ci", "success"), status("lint", "failure"), status("e2e", "error")},
+			expected: []string{"ci", "lint", "e2e"},
+		},
+		{
+			// Pending statuses would stay pending forever on the new head
+			from:     []github.RepoStatus{status("ci", "pending"), status("lint", "success")},
+			expected: []string{"lint"},
+		},
+		{
+			// Statuses already reported for the new head are kept as-is
+			from:     []github.RepoStatus{status("ci", "success"), status("lint", "success")},
+			to:       []github.RepoStatus{status("ci", "pending")},
+			expected: []string{"lint"},
+		},
+		{
+			from:     []github.RepoStatus{status("ci/test", "success"), status("deploy", "success")},
+			pattern:  "^ci/",
+			expected: []string{"ci/test"},
+		},
+	}
+
+	for i, tc := range testcases {
+		actual := []string{}
+		for _, st := range statusesToMirror(tc.from, tc.to, matcher(tc.pattern)) {
+			actual = append(actual, st.GetContext())
+		}
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected statuses: expected=%v, got=%v", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestCheckRunsToMirror(t *testing.T) {
+	run := func(name, status string) *github.CheckRun {
+		return &github.CheckRun{Name: github.String(name), Status: github.String(status)}
+	}
+
+	testcases := []struct {
+		from, to []*github.CheckRun
+		pattern  string
+		expected []string
+	}{
+		{
+			from:     []*github.CheckRun{run("build", "completed"), run("test", "in_progress"), run("lint", "queued")},
+			expected: []string{"build"},
+		},
+		{
+			from:     []*github.CheckRun{run("build", "completed"), run("test", "completed")},
+			to:       []*github.CheckRun{run("test", "queued")},
+			expected: []string{"build"},
+		},
+		{
+			from:     []*github.CheckRun{run("build", "completed"), run("test", "completed")},
+			pattern:  "^te",
+			expected: []string{"test"},
+		},
+	}
+
+	for i, tc := range testcases {
+		actual := []string{}
+		for _, r := range checkRunsToMirror(tc.from, tc.to, matcher(tc.pattern)) {
+			actual = append(actual, r.GetName())
+		}
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("testcases[%d]: unexpected check runs: expected=%v, got=%v", i, tc.expected, actual)
+		}
+	}
+}
+
+func matcher(pattern string) func(string) bool {
+	if pattern == "" {
+		return func(string) bool { return true }
+	}
+	return regexp.MustCompile(pattern).MatchString
+}
@@ -11,7 +11,17 @@ import (
 )
 
 func RunCmd(cmd string, args []string) (string, string, error) {
+	return RunCmdIn("", nil, cmd, args)
+}
+
+// RunCmdIn is RunCmd that runs the command in the working directory `dir` with
+// the additional environment variables `env` given in the `KEY=VALUE` form.
+func RunCmdIn(dir string, env []string, cmd string, args []string) (string, string, error) {
 	c := exec.Command(cmd, args...)
+	c.Dir = dir
+	if len(env) > 0 {
+		c.Env = append(os.Environ(), env...)
+	}
 	//c.Stdin = os.Stdin
 	//var out bytes.Buffer
 	//cmd.Stdout = &out
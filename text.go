@@ -0,0 +1,14 @@
+package actions
+
+// MaxDescriptionLength is the maximum number of characters of commit status and deployment status descriptions
+const MaxDescriptionLength = 140
+
+// Truncate shortens s to at most max characters, replacing the rest with "...".
+// Unlike slicing the string, it never splits a multi-byte character.
+func Truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-3]) + "..."
+}
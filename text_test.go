@@ -0,0 +1,23 @@
+package actions
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	testcases := []struct {
+		s        string
+		max      int
+		expected string
+	}{
+		{s: "deployed", max: 8, expected: "deployed"},
+		{s: "deployed", max: 7, expected: "depl..."},
+		// Multi-byte characters count as one
+		{s: "デプロイしました", max: 8, expected: "デプロイしました"},
+		{s: "デプロイしました", max: 6, expected: "デプロ..."},
+	}
+
+	for i, tc := range testcases {
+		if actual := Truncate(tc.s, tc.max); actual != tc.expected {
+			t.Errorf("testcases[%d]: unexpected result: expected=%q, got=%q", i, tc.expected, actual)
+		}
+	}
+}